@@ -0,0 +1,160 @@
+package myrient_browser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const bookmarksFileName = "bookmarks.toml"
+
+// Bookmark is a named directory on the mirror. Path is stored decoded so the
+// file is easy to edit by hand.
+type Bookmark struct {
+	Name string `toml:"name"`
+	Path string `toml:"path"`
+}
+
+type bookmarkFile struct {
+	Bookmarks []Bookmark `toml:"bookmark"`
+}
+
+// DefaultBookmarksPath returns the location of the bookmarks file, next to
+// the config file in the user's XDG config directory.
+func DefaultBookmarksPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, appName, bookmarksFileName)
+}
+
+// LoadBookmarks reads the bookmarks file at path. A missing file holds no
+// bookmarks.
+func LoadBookmarks(path string) ([]Bookmark, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	var f bookmarkFile
+	if _, err := toml.DecodeFile(path, &f); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read bookmarks %s: %w", path, err)
+	}
+	return f.Bookmarks, nil
+}
+
+// writeBookmarks atomically replaces the bookmarks file at path.
+func writeBookmarks(path string, bookmarks []Bookmark) error {
+	if path == "" {
+		return nil
+	}
+
+	return writeFileAtomic(path, func(w io.Writer) error {
+		return toml.NewEncoder(w).Encode(bookmarkFile{Bookmarks: bookmarks})
+	})
+}
+
+// ExpandBookmark replaces a location of the form "@name" with the path of
+// the bookmark called name. Other locations are returned unchanged.
+func ExpandBookmark(bookmarks []Bookmark, location string) (string, error) {
+	name, ok := strings.CutPrefix(strings.TrimSpace(location), "@")
+	if !ok {
+		return location, nil
+	}
+	if i := bookmarkIndex(bookmarks, name); i >= 0 {
+		return bookmarks[i].Path, nil
+	}
+
+	names := make([]string, len(bookmarks))
+	for i, b := range bookmarks {
+		names[i] = b.Name
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("unknown bookmark %q, no bookmarks saved", name)
+	}
+	return "", fmt.Errorf("unknown bookmark %q (have %s)", name, strings.Join(names, ", "))
+}
+
+func bookmarkIndex(bookmarks []Bookmark, name string) int {
+	return slices.IndexFunc(bookmarks, func(b Bookmark) bool { return b.Name == name })
+}
+
+// saveBookmarks persists the bookmarks.
+func (m *Model) saveBookmarks() {
+	if err := writeBookmarks(m.opts.BookmarksPath, m.bookmarks); err != nil {
+		m.lastError = fmt.Sprintf("failed to save bookmarks: %v", err)
+	}
+}
+
+// startNaming prompts for the name of a new bookmark for the current
+// directory, or for a new name for the bookmark at index.
+func (m *Model) startNaming(index int) tea.Cmd {
+	m.naming = true
+	m.renaming = index
+	if index >= 0 {
+		m.bookmarkInput.SetValue(m.bookmarks[index].Name)
+	} else {
+		name := strings.TrimSuffix(decodePath(m.currentPath), "/")
+		m.bookmarkInput.SetValue(name[strings.LastIndex(name, "/")+1:])
+	}
+	m.bookmarkInput.CursorEnd()
+	m.bookmarkInput.Focus()
+	return textinput.Blink
+}
+
+// finishNaming adds or renames the bookmark with the name typed at the
+// prompt.
+func (m *Model) finishNaming() {
+	name := strings.TrimSpace(m.bookmarkInput.Value())
+	switch i := bookmarkIndex(m.bookmarks, name); {
+	case name == "":
+		m.status = "A bookmark needs a name"
+		return
+	case i >= 0 && i != m.renaming:
+		m.status = fmt.Sprintf("There is already a bookmark called %s", name)
+		return
+	}
+
+	m.naming = false
+	m.bookmarkInput.Blur()
+	if m.renaming >= 0 {
+		m.bookmarks[m.renaming].Name = name
+		m.status = "Renamed bookmark to " + name
+	} else {
+		m.bookmarks = append(m.bookmarks, Bookmark{Name: name, Path: decodePath(m.currentPath)})
+		m.status = fmt.Sprintf("Bookmarked %s as %s", decodePath(m.currentPath), name)
+	}
+	m.saveBookmarks()
+}
+
+// deleteBookmark removes the bookmark under the picker cursor.
+func (m *Model) deleteBookmark() {
+	if m.bookmarkCursor >= len(m.bookmarks) {
+		return
+	}
+	name := m.bookmarks[m.bookmarkCursor].Name
+	m.bookmarks = slices.Delete(m.bookmarks, m.bookmarkCursor, m.bookmarkCursor+1)
+	m.bookmarkCursor = max(min(m.bookmarkCursor, len(m.bookmarks)-1), 0)
+	m.saveBookmarks()
+	m.status = "Deleted bookmark " + name
+}
+
+// openBookmark closes the picker and goes to the bookmark under its cursor.
+func (m *Model) openBookmark() tea.Cmd {
+	if m.bookmarkCursor >= len(m.bookmarks) {
+		return nil
+	}
+	m.showBookmarks = false
+	return m.navigateTo(m.bookmarks[m.bookmarkCursor].Path)
+}
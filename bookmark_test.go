@@ -0,0 +1,74 @@
+package myrient_browser
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExpandBookmark(t *testing.T) {
+	bookmarks := []Bookmark{
+		{Name: "psx", Path: "Redump/Sony - PlayStation/"},
+		{Name: "gb", Path: "No-Intro/Nintendo - Game Boy/"},
+	}
+
+	tests := []struct {
+		bookmarks []Bookmark
+		location  string
+		want      string
+		err       string
+	}{
+		{bookmarks, "@psx", "Redump/Sony - PlayStation/", ""},
+		{bookmarks, "  @gb ", "No-Intro/Nintendo - Game Boy/", ""},
+		{bookmarks, "Redump/", "Redump/", ""},
+		{bookmarks, "https://example.org/files/@psx/", "https://example.org/files/@psx/", ""},
+		{bookmarks, "@PSX", "", `unknown bookmark "PSX" (have psx, gb)`},
+		{nil, "@psx", "", `unknown bookmark "psx", no bookmarks saved`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			got, err := ExpandBookmark(tt.bookmarks, tt.location)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("ExpandBookmark(%q) error = %v, want %q", tt.location, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExpandBookmark(%q) failed: %v", tt.location, err)
+			}
+			if got != tt.want {
+				t.Errorf("ExpandBookmark(%q) = %q, want %q", tt.location, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBookmarksFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", "bookmarks.toml")
+
+	if bookmarks, err := LoadBookmarks(path); err != nil || bookmarks != nil {
+		t.Fatalf("LoadBookmarks of a missing file = %v, %v, want no bookmarks", bookmarks, err)
+	}
+
+	want := []Bookmark{{Name: "psx", Path: "Redump/Sony - PlayStation/"}, {Name: "odd", Path: `100% "Orange"/`}}
+	if err := writeBookmarks(path, want); err != nil {
+		t.Fatalf("writeBookmarks failed: %v", err)
+	}
+	got, err := LoadBookmarks(path)
+	if err != nil {
+		t.Fatalf("LoadBookmarks failed: %v", err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("LoadBookmarks = %+v, want %+v", got, want)
+	}
+
+	if err := os.WriteFile(path, []byte("[[bookmark]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBookmarks(path); err == nil || !strings.HasPrefix(err.Error(), "failed to read bookmarks") {
+		t.Errorf("LoadBookmarks of a broken file error = %v, want a read error", err)
+	}
+}
@@ -0,0 +1,155 @@
+package myrient_browser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	defaultCacheTTL  = time.Hour
+	cacheDirName     = "listings"
+	cacheFileVersion = 2
+)
+
+// listing is a parsed directory page along with the validators needed to
+// revalidate it.
+type listing struct {
+	Version      int         `json:"version"`
+	BaseURL      string      `json:"base_url"`
+	Path         string      `json:"path"`
+	Entries      []fileEntry `json:"entries"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+	Fetched      time.Time   `json:"fetched"`
+}
+
+// listingCache stores parsed directory listings on disk. A nil cache is
+// valid and never returns anything.
+type listingCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// DefaultCacheDir returns the directory listings are cached in, under the
+// user's cache directory.
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, appName, cacheDirName)
+}
+
+// newListingCache returns a cache in dir, or nil when dir is empty or ttl is
+// negative.
+func newListingCache(dir string, ttl time.Duration) *listingCache {
+	if dir == "" || ttl < 0 {
+		return nil
+	}
+	return &listingCache{dir: dir, ttl: ttl}
+}
+
+func (c *listingCache) file(baseURL, path string) string {
+	sum := sha256.Sum256([]byte(baseURL + path))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:16])+".json")
+}
+
+// get returns the cached listing of path, or nil if there is none.
+func (c *listingCache) get(baseURL, path string) (*listing, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(c.file(baseURL, path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var l listing
+	if err := json.Unmarshal(data, &l); err != nil || l.Version != cacheFileVersion ||
+		l.BaseURL != baseURL || l.Path != path {
+		return nil, nil
+	}
+	return &l, nil
+}
+
+// put stores l, replacing any previous copy.
+func (c *listingCache) put(l *listing) error {
+	if c == nil {
+		return nil
+	}
+
+	l.Version = cacheFileVersion
+	// Listings are stored from several goroutines at once, which
+	// writeFileAtomic allows.
+	return writeFileAtomic(c.file(l.BaseURL, l.Path), func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(l)
+	})
+}
+
+// stale reports whether l is older than the cache TTL and should be
+// revalidated.
+func (c *listingCache) stale(l *listing) bool {
+	return c != nil && time.Since(l.Fetched) >= c.ttl
+}
+
+// revalidateDirectory checks a stale cached listing with a conditional
+// request. It returns a dirLoadedMsg only if the listing changed.
+func revalidateDirectory(opts Options, cache *listingCache, cached *listing) tea.Cmd {
+	return func() tea.Msg {
+		l, notModified, err := fetchListing(opts, cached.Path, cached)
+		if err != nil {
+			// Keep showing the cached copy; it will be retried next time.
+			return nil
+		}
+
+		if notModified || sameEntries(l.Entries, cached.Entries) {
+			if notModified {
+				l = cached
+			}
+			l.Fetched = time.Now()
+			_ = cache.put(l)
+			return nil
+		}
+
+		_ = cache.put(l)
+		return dirLoadedMsg{path: l.Path, entries: l.Entries, revalidated: true}
+	}
+}
+
+func sameEntries(a, b []fileEntry) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name || a[i].Path != b[i].Path ||
+			a[i].Size != b[i].Size || !a[i].ModTime.Equal(b[i].ModTime) {
+			return false
+		}
+	}
+	return true
+}
+
+// refreshDirectory fetches path unconditionally and updates the cache,
+// replacing the shown listing in place.
+func refreshDirectory(opts Options, path string, cache *listingCache) tea.Cmd {
+	return func() tea.Msg {
+		l, _, err := fetchListing(opts, path, nil)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		_ = cache.put(l)
+		return dirLoadedMsg{path: path, entries: l.Entries, revalidated: true}
+	}
+}
@@ -0,0 +1,210 @@
+package myrient_browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestListingCache(t *testing.T) {
+	if c := newListingCache("", time.Hour); c != nil {
+		t.Error("a cache without a directory is enabled")
+	}
+	if c := newListingCache(t.TempDir(), -1); c != nil {
+		t.Error("a cache with a negative TTL is enabled")
+	}
+
+	c := newListingCache(t.TempDir(), time.Hour)
+	entries := []fileEntry{{Name: "a.zip", Path: "a.zip", Size: 4}}
+	if err := c.put(&listing{BaseURL: "https://example.org/files/", Path: "A/", Entries: entries, Fetched: time.Now()}); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		baseURL string
+		path    string
+		found   bool
+	}{
+		{"same listing", "https://example.org/files/", "A/", true},
+		{"other path", "https://example.org/files/", "B/", false},
+		{"other mirror", "https://example.com/files/", "A/", false},
+	}
+	for _, tt := range tests {
+		l, err := c.get(tt.baseURL, tt.path)
+		if err != nil {
+			t.Fatalf("%s: get failed: %v", tt.name, err)
+		}
+		if (l != nil) != tt.found {
+			t.Errorf("%s: found = %v, want %v", tt.name, l != nil, tt.found)
+		}
+		if l != nil && !sameEntries(l.Entries, entries) {
+			t.Errorf("%s: entries = %+v, want %+v", tt.name, l.Entries, entries)
+		}
+	}
+
+	for _, tt := range []struct {
+		age   time.Duration
+		stale bool
+	}{
+		{0, false},
+		{59 * time.Minute, false},
+		{time.Hour, true},
+		{48 * time.Hour, true},
+	} {
+		if got := c.stale(&listing{Fetched: time.Now().Add(-tt.age)}); got != tt.stale {
+			t.Errorf("stale after %s = %v, want %v", tt.age, got, tt.stale)
+		}
+	}
+}
+
+func TestRevalidateDirectory(t *testing.T) {
+	const (
+		oldPage = `[{"name":"a.zip","type":"file","size":4}]`
+		newPage = `[{"name":"a.zip","type":"file","size":4},{"name":"b.zip","type":"file","size":2}]`
+	)
+
+	tests := []struct {
+		name    string
+		etag    string
+		page    string
+		status  int
+		changed bool
+		fresh   bool
+	}{
+		{"not modified", `"v1"`, oldPage, http.StatusOK, false, true},
+		{"changed", `"v2"`, newPage, http.StatusOK, true, true},
+		{"new validator, same entries", `"v2"`, oldPage, http.StatusOK, false, true},
+		{"server error", `"v2"`, newPage, http.StatusInternalServerError, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var conditional bool
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conditional = r.Header.Get("If-None-Match") == `"v1"`
+				if tt.status != http.StatusOK {
+					w.WriteHeader(tt.status)
+					return
+				}
+				if r.Header.Get("If-None-Match") == tt.etag {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("ETag", tt.etag)
+				_, _ = w.Write([]byte(tt.page))
+			}))
+			defer srv.Close()
+
+			opts := DefaultOptions()
+			opts.BaseURL = srv.URL + "/files/"
+			cache := newListingCache(t.TempDir(), time.Hour)
+			cached := &listing{
+				BaseURL: opts.BaseURL,
+				Path:    "A/",
+				Entries: []fileEntry{{Name: "..", Path: "../", Size: -1}, {Name: "a.zip", Path: "a.zip", Size: 4}},
+				ETag:    `"v1"`,
+				Fetched: time.Now().Add(-2 * time.Hour),
+			}
+			if err := cache.put(cached); err != nil {
+				t.Fatal(err)
+			}
+
+			msg := revalidateDirectory(opts, cache, cached)()
+			if !conditional {
+				t.Error("the revalidation didn't send the cached ETag")
+			}
+			loaded, ok := msg.(dirLoadedMsg)
+			if ok != tt.changed {
+				t.Fatalf("revalidation returned %#v, want a new listing %v", msg, tt.changed)
+			}
+			if ok && (!loaded.revalidated || loaded.path != "A/" || len(loaded.entries) != 3) {
+				t.Errorf("revalidation returned %+v, want the 3 new entries of A/", loaded)
+			}
+
+			l, err := cache.get(opts.BaseURL, "A/")
+			if err != nil || l == nil {
+				t.Fatalf("the listing is no longer cached: %v", err)
+			}
+			if fresh := !cache.stale(l); fresh != tt.fresh {
+				t.Errorf("cached listing fresh = %v, want %v", fresh, tt.fresh)
+			}
+		})
+	}
+}
+
+func TestRevalidateSortedListing(t *testing.T) {
+	srv := httptest.NewServer(mirrorHandler(map[string]string{
+		"/files/": `[{"name":"a.zip","type":"file","size":9},{"name":"b.zip","type":"file","size":1}]`,
+	}))
+	defer srv.Close()
+
+	opts := DefaultOptions()
+	opts.BaseURL = srv.URL + "/files/"
+	opts.OutputDir = t.TempDir()
+	opts.CacheDir = t.TempDir()
+	opts.CacheTTL = 0
+	first := InitialModel(opts)
+	runCmd(first, first.Init())
+
+	// The cached copy is shown sorted, then found unchanged on the server.
+	m := InitialModel(opts)
+	m.sortMode = sortSizeAsc
+	runCmd(m, m.Init())
+	if m.status == "Listing refreshed" {
+		t.Error("an unchanged listing was refreshed because it is shown sorted")
+	}
+	if got := m.entries[0].Name; got != "b.zip" {
+		t.Errorf("first entry = %q, want the smallest, b.zip", got)
+	}
+	l, err := m.cache.get(opts.BaseURL, "")
+	if err != nil || l == nil || l.Entries[0].Name != "a.zip" {
+		t.Errorf("cached listing = %+v, %v, want it in server order", l, err)
+	}
+}
+
+func TestLoadDirectoryFromCache(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"name":"a.zip","type":"file","size":4}]`))
+	}))
+	defer srv.Close()
+
+	opts := DefaultOptions()
+	opts.BaseURL = srv.URL + "/files/"
+	cache := newListingCache(t.TempDir(), time.Hour)
+
+	tests := []struct {
+		name     string
+		refresh  bool
+		age      time.Duration
+		requests int
+		stale    bool
+	}{
+		{"first load", false, 0, 1, false},
+		{"fresh copy", false, 0, 0, false},
+		{"stale copy", false, 2 * time.Hour, 0, true},
+		{"refresh", true, 0, 1, false},
+	}
+	for _, tt := range tests {
+		if tt.age > 0 {
+			l, _ := cache.get(opts.BaseURL, "")
+			l.Fetched = time.Now().Add(-tt.age)
+			if err := cache.put(l); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		requests = 0
+		msg, ok := loadDirectory(opts, "", cache, tt.refresh)().(dirLoadedMsg)
+		if !ok {
+			t.Fatalf("%s: loadDirectory didn't load the listing", tt.name)
+		}
+		if requests != tt.requests || msg.stale != tt.stale {
+			t.Errorf("%s: %d requests, stale %v, want %d requests, stale %v", tt.name, requests, msg.stale, tt.requests, tt.stale)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/alexferl/myrient_browser"
+)
+
+func runGet(args []string) error {
+	fs := flag.NewFlagSet("get", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: myrient_browser get [flags] <path or @bookmark>")
+		fs.PrintDefaults()
+	}
+	loadOptions := bindOptionFlags(fs)
+	match := fs.String("match", "", "only download files whose name matches this glob")
+	interval := fs.Duration("interval", 10*time.Second, "how often to print aggregate progress (0 to disable)")
+	asJSON := fs.Bool("json", false, "print the summary as JSON")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return errors.New("get takes exactly one path")
+	}
+
+	opts, err := loadOptions()
+	if err != nil {
+		return err
+	}
+
+	location, err := expandBookmark(positional[0])
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	summary, err := myrient_browser.Get(ctx, opts, location, myrient_browser.GetOptions{
+		Match:    *match,
+		Progress: os.Stderr,
+		Interval: *interval,
+	})
+	// The summary is always printed, even when nothing matched, so that
+	// scripts reading it have something to parse.
+	if printErr := printSummary(summary, *asJSON); printErr != nil && err == nil {
+		err = printErr
+	}
+	if err != nil {
+		return err
+	}
+	if summary.Failed > 0 {
+		return fmt.Errorf("%d of %d files failed", summary.Failed, summary.OK+summary.Failed+summary.Skipped)
+	}
+	return nil
+}
+
+func printSummary(s myrient_browser.Summary, asJSON bool) error {
+	if asJSON {
+		failures := s.Failures
+		if failures == nil {
+			failures = []string{}
+		}
+		type change struct {
+			Action string `json:"action"`
+			Path   string `json:"path"`
+			Size   int64  `json:"size,omitempty"`
+		}
+		// Only a dry run has a plan, which is then listed even if empty.
+		var plan *[]change
+		if s.Plan != nil {
+			changes := make([]change, len(s.Plan))
+			for i, c := range s.Plan {
+				changes[i] = change{c.Action, c.Path, c.Size}
+			}
+			plan = &changes
+		}
+		return json.NewEncoder(os.Stdout).Encode(struct {
+			OK       int       `json:"ok"`
+			Failed   int       `json:"failed"`
+			Skipped  int       `json:"skipped"`
+			Deleted  int       `json:"deleted"`
+			Bytes    int64     `json:"bytes"`
+			Duration float64   `json:"duration_seconds"`
+			Failures []string  `json:"failures"`
+			Plan     *[]change `json:"plan,omitempty"`
+		}{s.OK, s.Failed, s.Skipped, s.Deleted, s.Bytes, s.Duration.Seconds(), failures, plan})
+	}
+
+	for _, c := range s.Plan {
+		if c.Action == "delete" {
+			fmt.Printf("delete %s\n", c.Path)
+		} else {
+			fmt.Printf("%s %s (%.2f MB)\n", c.Action, c.Path, float64(c.Size)/1024/1024)
+		}
+	}
+	_, err := fmt.Printf("ok=%d failed=%d skipped=%d deleted=%d bytes=%d duration=%s\n",
+		s.OK, s.Failed, s.Skipped, s.Deleted, s.Bytes, s.Duration.Round(time.Millisecond))
+	return err
+}
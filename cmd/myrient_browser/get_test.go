@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunGet(t *testing.T) {
+	pages := map[string]string{
+		"/files/Games/":       `[{"name":"a.zip","type":"file","size":4}]`,
+		"/files/Games/a.zip":  "aaaa",
+		"/files/Broken/":      `[{"name":"a.zip","type":"file","size":4},{"name":"gone.zip","type":"file","size":4}]`,
+		"/files/Broken/a.zip": "aaaa",
+		"/files/Empty/":       `[]`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/") {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		http.ServeContent(w, r, r.URL.Path, time.Time{}, strings.NewReader(page))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		location string
+		ok       int
+		failed   []string
+		err      string
+	}{
+		{"Games", 1, []string{}, ""},
+		{"Broken", 1, []string{"gone.zip"}, "1 of 2 files failed"},
+		{"Empty", 0, []string{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			dir := t.TempDir()
+			args := []string{
+				"--config", filepath.Join(dir, "missing.toml"),
+				"--base-url", srv.URL + "/files/",
+				"--output", dir,
+				"--skip-scan",
+				"--interval", "0",
+				"--json",
+				tt.location,
+			}
+
+			var err error
+			out := captureStdout(t, func() { err = runGet(args) })
+			if tt.err == "" && err != nil || tt.err != "" && (err == nil || err.Error() != tt.err) {
+				t.Errorf("runGet error = %v, want %q", err, tt.err)
+			}
+
+			var summary struct {
+				OK       int      `json:"ok"`
+				Failed   int      `json:"failed"`
+				Failures []string `json:"failures"`
+			}
+			if err := json.Unmarshal(out, &summary); err != nil {
+				t.Fatalf("summary %q isn't JSON: %v", out, err)
+			}
+			if summary.OK != tt.ok || summary.Failed != len(tt.failed) || strings.Join(summary.Failures, ",") != strings.Join(tt.failed, ",") {
+				t.Errorf("summary = %+v, want %d ok and failures %q", summary, tt.ok, tt.failed)
+			}
+		})
+	}
+}
+
+// captureStdout returns what fn writes to os.Stdout.
+func captureStdout(t *testing.T, fn func()) []byte {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- data
+	}()
+	fn()
+	_ = w.Close()
+	return <-done
+}
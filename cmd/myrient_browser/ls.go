@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/alexferl/myrient_browser"
+)
+
+func runLs(args []string) error {
+	fs := flag.NewFlagSet("ls", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: myrient_browser ls [flags] <path or @bookmark>")
+		fs.PrintDefaults()
+	}
+	loadOptions := bindOptionFlags(fs)
+	recursive := fs.Bool("recursive", false, "list subdirectories recursively")
+	filter := fs.String("filter", "", "only show entries whose name contains this text")
+	asJSON := fs.Bool("json", false, "print entries as JSON")
+	asTSV := fs.Bool("tsv", false, "print entries as tab-separated values")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 1 {
+		fs.Usage()
+		return errors.New("ls takes at most one path")
+	}
+	if *asJSON && *asTSV {
+		return errors.New("--json and --tsv are mutually exclusive")
+	}
+
+	opts, err := loadOptions()
+	if err != nil {
+		return err
+	}
+
+	path := ""
+	if len(positional) == 1 {
+		path, err = expandBookmark(positional[0])
+		if err != nil {
+			return err
+		}
+	}
+
+	entries, err := myrient_browser.List(opts, path, myrient_browser.ListOptions{
+		Recursive: *recursive,
+		Filter:    *filter,
+	})
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *asJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if entries == nil {
+			entries = []myrient_browser.Entry{}
+		}
+		return enc.Encode(entries)
+	case *asTSV:
+		fmt.Println("type\tpath\tsize\tmodified\turl")
+		for _, e := range entries {
+			modified := ""
+			if !e.Modified.IsZero() {
+				modified = e.Modified.Format(time.RFC3339)
+			}
+			fmt.Printf("%s\t%s\t%d\t%s\t%s\n", entryType(e), e.Path, e.Size, modified, e.URL)
+		}
+	default:
+		for _, e := range entries {
+			fmt.Println(e.Path)
+		}
+	}
+
+	return nil
+}
+
+func entryType(e myrient_browser.Entry) string {
+	if e.Dir {
+		return "dir"
+	}
+	return "file"
+}
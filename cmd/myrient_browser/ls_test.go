@@ -0,0 +1,136 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunLs(t *testing.T) {
+	pages := map[string]string{
+		"/files/Games/":         `[{"name":"Sub Dir","type":"directory"},{"name":"a.zip","type":"file","size":4,"mtime":"Fri, 15 Mar 2024 12:30:45 GMT"}]`,
+		"/files/Games/Sub Dir/": `[{"name":"b+c (USA).zip","type":"file","size":2}]`,
+		"/files/Empty/":         `[]`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/files/Broken/" {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		http.ServeContent(w, r, r.URL.Path, time.Time{}, strings.NewReader(page))
+	}))
+	defer srv.Close()
+	base := srv.URL + "/files/"
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+		err  string
+	}{
+		{
+			name: "plain",
+			args: []string{"Games"},
+			want: "Games/Sub Dir/\nGames/a.zip\n",
+		},
+		{
+			name: "recursive",
+			args: []string{"--recursive", "Games"},
+			want: "Games/Sub Dir/\nGames/Sub Dir/b+c (USA).zip\nGames/a.zip\n",
+		},
+		{
+			name: "filter",
+			args: []string{"--recursive", "--filter", "USA", "Games"},
+			want: "Games/Sub Dir/b+c (USA).zip\n",
+		},
+		{
+			name: "url",
+			args: []string{base + "Games/Sub%20Dir/"},
+			want: "Games/Sub Dir/b+c (USA).zip\n",
+		},
+		{
+			name: "json",
+			args: []string{"--json", "Games"},
+			want: `[
+  {
+    "name": "Sub Dir/",
+    "path": "Games/Sub Dir/",
+    "url": "` + base + `Games/Sub%20Dir/",
+    "dir": true,
+    "size": -1
+  },
+  {
+    "name": "a.zip",
+    "path": "Games/a.zip",
+    "url": "` + base + `Games/a.zip",
+    "dir": false,
+    "size": 4,
+    "modified": "2024-03-15T12:30:45Z"
+  }
+]
+`,
+		},
+		{
+			name: "empty json",
+			args: []string{"--json", "Empty"},
+			want: "[]\n",
+		},
+		{
+			name: "tsv",
+			args: []string{"--tsv", "Games"},
+			want: "type\tpath\tsize\tmodified\turl\n" +
+				"dir\tGames/Sub Dir/\t-1\t\t" + base + "Games/Sub%20Dir/\n" +
+				"file\tGames/a.zip\t4\t2024-03-15T12:30:45Z\t" + base + "Games/a.zip\n",
+		},
+		{
+			name: "json and tsv",
+			args: []string{"--json", "--tsv", "Games"},
+			err:  "--json and --tsv are mutually exclusive",
+		},
+		{
+			name: "not found",
+			args: []string{"Missing"},
+			err:  "failed to load directory Missing/: Not Found",
+		},
+		{
+			name: "server error",
+			args: []string{"Broken"},
+			err:  "failed to load directory Broken/: Internal Server Error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{
+				"--config", filepath.Join(t.TempDir(), "missing.toml"),
+				"--base-url", base,
+			}, tt.args...)
+
+			var err error
+			out := captureStdout(t, func() { err = runLs(args) })
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Errorf("runLs error = %v, want %q", err, tt.err)
+				}
+				if len(out) > 0 {
+					t.Errorf("runLs printed %q on failure", out)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("runLs failed: %v", err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("output = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
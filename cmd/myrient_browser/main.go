@@ -1,9 +1,11 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/alexferl/myrient_browser"
@@ -11,10 +13,47 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			if err := cmd(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	fs := flag.NewFlagSet("myrient_browser", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: myrient_browser [flags] [path, URL or @bookmark]")
+		fmt.Fprintln(fs.Output(), "       myrient_browser ls|get|sync [flags] ...")
+		fs.PrintDefaults()
+	}
+	loadOptions := bindOptionFlags(fs)
+	positional, err := parseArgs(fs, os.Args[1:])
+	if err != nil || len(positional) > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	opts, err := loadOptions()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(positional) == 1 {
+		opts.StartPath = positional[0]
+	}
+	opts.StatePath = myrient_browser.DefaultStatePath()
+	opts.CacheDir = myrient_browser.DefaultCacheDir()
+	opts.QueuePath = myrient_browser.DefaultQueuePath()
+	opts.IndexPath = myrient_browser.DefaultIndexPath()
+	opts.BookmarksPath = myrient_browser.DefaultBookmarksPath()
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
-	p := tea.NewProgram(myrient_browser.InitialModel(), tea.WithAltScreen())
+	p := tea.NewProgram(myrient_browser.InitialModel(opts), tea.WithAltScreen(), tea.WithMouseCellMotion())
 
 	go func() {
 		<-sigChan
@@ -26,3 +65,104 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// commands maps subcommand names to their entry points. Each receives the
+// arguments following the subcommand name.
+var commands = map[string]func(args []string) error{
+	"ls":   runLs,
+	"get":  runGet,
+	"sync": runSync,
+}
+
+// expandBookmark resolves a location of the form "@name" to the path of the
+// bookmark called name.
+func expandBookmark(location string) (string, error) {
+	if !strings.HasPrefix(location, "@") {
+		return location, nil
+	}
+	bookmarks, err := myrient_browser.LoadBookmarks(myrient_browser.DefaultBookmarksPath())
+	if err != nil {
+		return "", err
+	}
+	return myrient_browser.ExpandBookmark(bookmarks, location)
+}
+
+// parseArgs parses fs and returns its positional arguments, allowing flags to
+// appear after them.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// bindOptionFlags registers the shared option flags on fs. The returned
+// function must be called after parsing; it loads the config file and
+// environment and then applies only the flags that were set explicitly.
+func bindOptionFlags(fs *flag.FlagSet) func() (myrient_browser.Options, error) {
+	defaults := myrient_browser.DefaultOptions()
+
+	configPath := fs.String("config", myrient_browser.DefaultConfigPath(), "path to the config file")
+	baseURL := fs.String("base-url", defaults.BaseURL, "mirror base URL")
+	workers := fs.Int("workers", defaults.Workers, "number of concurrent download workers")
+	outputDir := fs.String("output", defaults.OutputDir, "download directory")
+	skipScan := fs.Bool("skip-scan", defaults.SkipScan, "skip the file size pre-scan")
+	autoExtract := fs.Bool("extract", defaults.AutoExtract, "extract zip files after download")
+	extractToFolder := fs.Bool("extract-to-folder", defaults.ExtractToFolder, "extract each zip into its own folder")
+	deleteZip := fs.Bool("delete-zip", defaults.DeleteZip, "delete zip files after extraction")
+	maxDepth := fs.Int("max-depth", defaults.MaxDepth, "directory levels a recursive download descends (0 for no limit)")
+	cacheTTL := fs.Duration("cache-ttl", defaults.CacheTTL, "how long cached listings are used before revalidating (negative to disable)")
+	crawlDelay := fs.Duration("crawl-delay", defaults.CrawlDelay, "pause between directory requests while building the search index")
+
+	return func() (myrient_browser.Options, error) {
+		opts, err := myrient_browser.LoadOptions(*configPath)
+		if err != nil {
+			return opts, err
+		}
+
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "base-url":
+				opts.BaseURL = *baseURL
+				opts.SetExplicit("base_url")
+			case "workers":
+				opts.Workers = *workers
+				opts.SetExplicit("workers")
+			case "output":
+				opts.OutputDir = *outputDir
+				opts.SetExplicit("output_dir")
+			case "skip-scan":
+				opts.SkipScan = *skipScan
+				opts.SetExplicit("skip_scan")
+			case "extract":
+				opts.AutoExtract = *autoExtract
+				opts.SetExplicit("auto_extract")
+			case "extract-to-folder":
+				opts.ExtractToFolder = *extractToFolder
+				opts.SetExplicit("extract_to_folder")
+			case "delete-zip":
+				opts.DeleteZip = *deleteZip
+				opts.SetExplicit("delete_zip")
+			case "max-depth":
+				opts.MaxDepth = *maxDepth
+				opts.SetExplicit("max_depth")
+			case "cache-ttl":
+				opts.CacheTTL = *cacheTTL
+				opts.SetExplicit("cache_ttl")
+			case "crawl-delay":
+				opts.CrawlDelay = *crawlDelay
+				opts.SetExplicit("crawl_delay")
+			}
+		})
+
+		return opts, opts.Validate()
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBindOptionFlags(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(config, []byte("workers = 8\noutput_dir = \"/from/config\"\nauto_extract = true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		env      string
+		workers  int
+		output   string
+		extract  bool
+		explicit bool
+	}{
+		{"config file", nil, "", 8, "/from/config", true, false},
+		{"environment over config file", nil, "4", 4, "/from/config", true, true},
+		{"flags over environment", []string{"--workers", "2", "--extract=false", "--output", "/from/flag"}, "4", 2, "/from/flag", false, true},
+		{"flags between arguments", []string{"Redump/", "--workers", "2", "out"}, "", 2, "/from/config", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MYRIENT_WORKERS", tt.env)
+			if tt.env == "" {
+				_ = os.Unsetenv("MYRIENT_WORKERS")
+			}
+
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			loadOptions := bindOptionFlags(fs)
+			if _, err := parseArgs(fs, append([]string{"--config", config}, tt.args...)); err != nil {
+				t.Fatalf("parseArgs failed: %v", err)
+			}
+			opts, err := loadOptions()
+			if err != nil {
+				t.Fatalf("loading options failed: %v", err)
+			}
+
+			if opts.Workers != tt.workers || opts.OutputDir != tt.output || opts.AutoExtract != tt.extract {
+				t.Errorf("options = %d workers, output %s, extract %v, want %d, %s, %v",
+					opts.Workers, opts.OutputDir, opts.AutoExtract, tt.workers, tt.output, tt.extract)
+			}
+			if opts.IsExplicit("workers") != tt.explicit {
+				t.Errorf("workers explicit = %v, want %v", opts.IsExplicit("workers"), tt.explicit)
+			}
+		})
+	}
+}
+
+func TestBindDurationFlags(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(config, []byte("cache_ttl = \"2h\"\ncrawl_delay = \"1s\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		args  []string
+		ttl   time.Duration
+		delay time.Duration
+		err   string
+	}{
+		{"config file", nil, 2 * time.Hour, time.Second, ""},
+		{"flags over config file", []string{"--cache-ttl", "-1s", "--crawl-delay", "0s"}, -time.Second, 0, ""},
+		{"negative crawl delay", []string{"--crawl-delay", "-1s"}, 0, 0, "crawl delay must not be negative, got -1s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			loadOptions := bindOptionFlags(fs)
+			if _, err := parseArgs(fs, append([]string{"--config", config}, tt.args...)); err != nil {
+				t.Fatalf("parseArgs failed: %v", err)
+			}
+			opts, err := loadOptions()
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("loading options returned %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loading options failed: %v", err)
+			}
+			if opts.CacheTTL != tt.ttl || opts.CrawlDelay != tt.delay {
+				t.Errorf("cache TTL %s, crawl delay %s, want %s, %s", opts.CacheTTL, opts.CrawlDelay, tt.ttl, tt.delay)
+			}
+			if len(tt.args) > 0 && (!opts.IsExplicit("cache_ttl") || !opts.IsExplicit("crawl_delay")) {
+				t.Error("the duration flags weren't recorded as explicit")
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/alexferl/myrient_browser"
+)
+
+func runSync(args []string) error {
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: myrient_browser sync [flags] <remote-path or @bookmark> <local-dir>")
+		fs.PrintDefaults()
+	}
+	loadOptions := bindOptionFlags(fs)
+	prune := fs.Bool("delete", false, "delete local files that no longer exist upstream")
+	dryRun := fs.Bool("dry-run", false, "print the plan without changing anything")
+	interval := fs.Duration("interval", 10*time.Second, "how often to print aggregate progress (0 to disable)")
+	asJSON := fs.Bool("json", false, "print the summary, and the plan of a dry run, as JSON")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		fs.Usage()
+		return errors.New("sync takes a remote path and a local directory")
+	}
+
+	opts, err := loadOptions()
+	if err != nil {
+		return err
+	}
+
+	remote, err := expandBookmark(positional[0])
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	summary, err := myrient_browser.Sync(ctx, opts, remote, positional[1], myrient_browser.SyncOptions{
+		Delete:   *prune,
+		DryRun:   *dryRun,
+		Progress: os.Stderr,
+		Interval: *interval,
+	})
+	// As for get, the summary is printed even when the sync failed early.
+	if printErr := printSummary(summary, *asJSON); printErr != nil && err == nil {
+		err = printErr
+	}
+	if err != nil {
+		return err
+	}
+	if summary.Failed > 0 {
+		return fmt.Errorf("%d files failed", summary.Failed)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunSync(t *testing.T) {
+	pages := map[string]string{
+		"/files/Games/":      `[{"name":"a.zip","type":"file","size":4}]`,
+		"/files/Games/a.zip": "aaaa",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/") {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		http.ServeContent(w, r, r.URL.Path, time.Time{}, strings.NewReader(page))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		remote string
+		ok     int
+		err    bool
+	}{
+		{"Games", 1, false},
+		{"Missing", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.remote, func(t *testing.T) {
+			dir := t.TempDir()
+			args := []string{
+				"--config", filepath.Join(dir, "missing.toml"),
+				"--base-url", srv.URL + "/files/",
+				"--interval", "0",
+				"--json",
+				tt.remote,
+				filepath.Join(dir, "mirror"),
+			}
+
+			var err error
+			out := captureStdout(t, func() { err = runSync(args) })
+			if (err != nil) != tt.err {
+				t.Errorf("runSync error = %v, want an error %v", err, tt.err)
+			}
+
+			var summary struct {
+				OK     int `json:"ok"`
+				Failed int `json:"failed"`
+			}
+			if err := json.Unmarshal(out, &summary); err != nil {
+				t.Fatalf("summary %q isn't JSON: %v", out, err)
+			}
+			if summary.OK != tt.ok || summary.Failed != 0 {
+				t.Errorf("summary = %+v, want %d ok", summary, tt.ok)
+			}
+		})
+	}
+}
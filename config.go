@@ -0,0 +1,253 @@
+package myrient_browser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	appName        = "myrient_browser"
+	configFileName = "config.toml"
+)
+
+// Options configures the browser and the downloaders it starts.
+type Options struct {
+	BaseURL         string `toml:"base_url"`
+	Workers         int    `toml:"workers"`
+	OutputDir       string `toml:"output_dir"`
+	SkipScan        bool   `toml:"skip_scan"`
+	AutoExtract     bool   `toml:"auto_extract"`
+	ExtractToFolder bool   `toml:"extract_to_folder"`
+	DeleteZip       bool   `toml:"delete_zip"`
+
+	// MaxDepth limits how many directory levels a recursive download
+	// descends, counting the selected directories as the first level.
+	// Zero means no limit.
+	MaxDepth int `toml:"max_depth"`
+
+	// CacheTTL is how long a cached directory listing is served without
+	// being revalidated. A negative value disables the cache.
+	CacheTTL time.Duration `toml:"cache_ttl"`
+
+	// CrawlDelay is the pause between directory requests made while
+	// building the search index.
+	CrawlDelay time.Duration `toml:"crawl_delay"`
+
+	// Lister selects the directory index format: "auto" (the default),
+	// "myrient", "autoindex" or "json".
+	Lister string `toml:"lister"`
+
+	// Profiles are named download settings bound to remote path prefixes.
+	Profiles map[string]Profile `toml:"profiles"`
+
+	// Keymap selects the key bindings preset: "default" or "vim".
+	Keymap string `toml:"keymap"`
+
+	// Keys overrides the keys of actions by name, such as
+	// download = ["d", "ctrl+d"]. An empty list unbinds the action.
+	Keys map[string][]string `toml:"keys"`
+
+	// StartPath is the directory the browser opens in. It may be a plain
+	// path, an escaped path or a full URL under BaseURL.
+	StartPath string `toml:"-"`
+
+	// StatePath is where toggles and the last location are persisted
+	// between sessions. Persistence is disabled when it is empty.
+	StatePath string `toml:"-"`
+
+	// CacheDir is where directory listings are cached. Caching is disabled
+	// when it is empty.
+	CacheDir string `toml:"-"`
+
+	// QueuePath is where the download queue is persisted. The queue only
+	// lasts for the session when it is empty.
+	QueuePath string `toml:"-"`
+
+	// IndexPath is where the search index is stored. The index only lasts
+	// for the session when it is empty.
+	IndexPath string `toml:"-"`
+
+	// BookmarksPath is where bookmarks are stored. Bookmarks only last for
+	// the session when it is empty.
+	BookmarksPath string `toml:"-"`
+
+	// explicit holds the config names of the options set by an environment
+	// variable or a command line flag, which saved toggles don't override.
+	explicit map[string]bool
+}
+
+// SetExplicit records that the option with the config name was set by an
+// environment variable or a command line flag.
+func (o *Options) SetExplicit(name string) {
+	if o.explicit == nil {
+		o.explicit = make(map[string]bool)
+	}
+	o.explicit[name] = true
+}
+
+// IsExplicit reports whether the option with the config name was set by an
+// environment variable or a command line flag.
+func (o *Options) IsExplicit(name string) bool {
+	return o.explicit[name]
+}
+
+// DefaultOptions returns the options used when nothing is configured.
+func DefaultOptions() Options {
+	return Options{
+		BaseURL:    defaultBaseURL,
+		Workers:    defaultNumWorkers,
+		OutputDir:  defaultOutputDir,
+		CacheTTL:   defaultCacheTTL,
+		CrawlDelay: defaultCrawlDelay,
+	}
+}
+
+// DefaultConfigPath returns the location of the config file, honouring
+// MYRIENT_CONFIG and falling back to the user's XDG config directory.
+func DefaultConfigPath() string {
+	if p := os.Getenv("MYRIENT_CONFIG"); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, appName, configFileName)
+}
+
+// LoadOptions builds options from the defaults, the config file at path and
+// MYRIENT_* environment variables, in that order of precedence. A missing
+// config file is not an error.
+func LoadOptions(path string) (Options, error) {
+	opts := DefaultOptions()
+
+	if path != "" {
+		if _, err := toml.DecodeFile(path, &opts); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return opts, fmt.Errorf("failed to read config %s: %w", path, err)
+		}
+	}
+
+	if err := opts.applyEnv(); err != nil {
+		return opts, err
+	}
+
+	return opts, opts.Validate()
+}
+
+func (o *Options) applyEnv() error {
+	if v, ok := os.LookupEnv("MYRIENT_BASE_URL"); ok {
+		o.BaseURL = v
+		o.SetExplicit("base_url")
+	}
+	if v, ok := os.LookupEnv("MYRIENT_LISTER"); ok {
+		o.Lister = v
+		o.SetExplicit("lister")
+	}
+	if v, ok := os.LookupEnv("MYRIENT_OUTPUT_DIR"); ok {
+		o.OutputDir = v
+		o.SetExplicit("output_dir")
+	}
+	if v, ok := os.LookupEnv("MYRIENT_CACHE_TTL"); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid MYRIENT_CACHE_TTL %q: %w", v, err)
+		}
+		o.CacheTTL = d
+		o.SetExplicit("cache_ttl")
+	}
+	if v, ok := os.LookupEnv("MYRIENT_CRAWL_DELAY"); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid MYRIENT_CRAWL_DELAY %q: %w", v, err)
+		}
+		o.CrawlDelay = d
+		o.SetExplicit("crawl_delay")
+	}
+	if v, ok := os.LookupEnv("MYRIENT_WORKERS"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid MYRIENT_WORKERS %q: %w", v, err)
+		}
+		o.Workers = n
+		o.SetExplicit("workers")
+	}
+	if v, ok := os.LookupEnv("MYRIENT_MAX_DEPTH"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid MYRIENT_MAX_DEPTH %q: %w", v, err)
+		}
+		o.MaxDepth = n
+		o.SetExplicit("max_depth")
+	}
+
+	bools := []struct {
+		name   string
+		option string
+		dst    *bool
+	}{
+		{"MYRIENT_SKIP_SCAN", "skip_scan", &o.SkipScan},
+		{"MYRIENT_AUTO_EXTRACT", "auto_extract", &o.AutoExtract},
+		{"MYRIENT_EXTRACT_TO_FOLDER", "extract_to_folder", &o.ExtractToFolder},
+		{"MYRIENT_DELETE_ZIP", "delete_zip", &o.DeleteZip},
+	}
+	for _, b := range bools {
+		v, ok := os.LookupEnv(b.name)
+		if !ok {
+			continue
+		}
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", b.name, v, err)
+		}
+		*b.dst = parsed
+		o.SetExplicit(b.option)
+	}
+
+	return nil
+}
+
+// Validate checks the options and normalises the base URL so that paths can
+// be appended to it directly.
+func (o *Options) Validate() error {
+	if o.BaseURL == "" {
+		return errors.New("base URL must not be empty")
+	}
+	if !strings.HasSuffix(o.BaseURL, "/") {
+		o.BaseURL += "/"
+	}
+	if o.Workers < 1 {
+		return fmt.Errorf("workers must be at least 1, got %d", o.Workers)
+	}
+	if o.OutputDir == "" {
+		return errors.New("output directory must not be empty")
+	}
+	if o.CrawlDelay < 0 {
+		return fmt.Errorf("crawl delay must not be negative, got %s", o.CrawlDelay)
+	}
+	if o.MaxDepth < 0 {
+		return fmt.Errorf("max depth must not be negative, got %d", o.MaxDepth)
+	}
+	switch o.Lister {
+	case "", "auto":
+	default:
+		if _, err := listerFor(o.Lister, "", nil); err != nil {
+			return err
+		}
+	}
+	for name, p := range o.Profiles {
+		if err := p.validate(); err != nil {
+			return fmt.Errorf("profile %s: %w", name, err)
+		}
+	}
+	if _, err := newKeyMap(o.Keymap, o.Keys); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package myrient_browser
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		env      map[string]string
+		check    func(o Options) bool
+		explicit []string
+		err      string
+	}{
+		{
+			name: "defaults",
+			check: func(o Options) bool {
+				return o.BaseURL == defaultBaseURL && o.Workers == defaultNumWorkers && o.CacheTTL == defaultCacheTTL
+			},
+		},
+		{
+			name:   "config file",
+			config: "base_url = \"https://example.org/files\"\nworkers = 8\nauto_extract = true\ncache_ttl = \"1h\"\n",
+			check: func(o Options) bool {
+				return o.BaseURL == "https://example.org/files/" && o.Workers == 8 && o.AutoExtract && o.CacheTTL == time.Hour
+			},
+		},
+		{
+			name:   "environment over config file",
+			config: "workers = 8\nauto_extract = true\noutput_dir = \"/srv/roms\"\n",
+			env:    map[string]string{"MYRIENT_WORKERS": "2", "MYRIENT_AUTO_EXTRACT": "false", "MYRIENT_CRAWL_DELAY": "2s"},
+			check: func(o Options) bool {
+				return o.Workers == 2 && !o.AutoExtract && o.CrawlDelay == 2*time.Second && o.OutputDir == "/srv/roms"
+			},
+			explicit: []string{"auto_extract", "crawl_delay", "workers"},
+		},
+		{
+			name:   "missing config file",
+			config: "-",
+			check:  func(o Options) bool { return o.Workers == defaultNumWorkers },
+		},
+		{
+			name:   "invalid config file",
+			config: "workers = \"many\"\n",
+			err:    "failed to read config",
+		},
+		{
+			name: "invalid environment variable",
+			env:  map[string]string{"MYRIENT_SKIP_SCAN": "maybe"},
+			err:  `invalid MYRIENT_SKIP_SCAN "maybe"`,
+		},
+		{
+			name:   "invalid value",
+			config: "workers = 0\n",
+			err:    "workers must be at least 1, got 0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"MYRIENT_BASE_URL", "MYRIENT_WORKERS", "MYRIENT_AUTO_EXTRACT", "MYRIENT_SKIP_SCAN", "MYRIENT_CRAWL_DELAY"} {
+				t.Setenv(name, "")
+				_ = os.Unsetenv(name)
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			path := ""
+			if tt.config != "" {
+				path = filepath.Join(t.TempDir(), "config.toml")
+				if tt.config != "-" {
+					if err := os.WriteFile(path, []byte(tt.config), 0o644); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			opts, err := LoadOptions(path)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("LoadOptions error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadOptions failed: %v", err)
+			}
+			if !tt.check(opts) {
+				t.Errorf("LoadOptions returned %+v", opts)
+			}
+			for _, name := range []string{"base_url", "workers", "output_dir", "auto_extract", "crawl_delay", "skip_scan"} {
+				if want := slices.Contains(tt.explicit, name); opts.IsExplicit(name) != want {
+					t.Errorf("IsExplicit(%q) = %v, want %v", name, !want, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package myrient_browser
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// crawlCompleteMsg carries the download jobs found by crawlDirectories.
+type crawlCompleteMsg struct {
+	stats *downloadStats
+	files []fileInfo
+	// owners holds the queue item ID of each job for queue downloads.
+	owners []int64
+}
+
+// crawlDirectories lists dirs under basePath recursively, up to
+// opts.MaxDepth levels, and returns a job for every file that passes match
+// along with the given top-level files. Local paths mirror the remote tree
+// under the output directory.
+func crawlDirectories(basePath string, files, dirs []fileEntry, match func(fileEntry) bool, stats *downloadStats, ctx context.Context, opts Options) tea.Cmd {
+	return func() tea.Msg {
+		var fileInfos []fileInfo
+		add := func(dir string, file fileEntry) error {
+			info, err := newFileInfo(dir, outputDirFor(dir, opts), file, opts)
+			if err != nil {
+				return err
+			}
+			fileInfos = append(fileInfos, info)
+			atomic.AddInt32(&stats.filesFound, 1)
+			return nil
+		}
+
+		for _, file := range files {
+			if err := add(basePath, file); err != nil {
+				return errMsg{err: err, stats: stats}
+			}
+		}
+
+		depth := -1
+		if opts.MaxDepth > 0 {
+			depth = opts.MaxDepth - 1
+		}
+
+		for _, dir := range dirs {
+			atomic.AddInt32(&stats.dirsCrawled, 1)
+			err := walkDirectory(opts, basePath+dir.Path, depth, func(parent string, entry fileEntry) error {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				if strings.HasSuffix(entry.Path, "/") {
+					atomic.AddInt32(&stats.dirsCrawled, 1)
+					return nil
+				}
+				if !match(entry) {
+					return nil
+				}
+				return add(parent, entry)
+			})
+			if ctx.Err() != nil {
+				return crawlCompleteMsg{stats: stats}
+			}
+			if err != nil {
+				return errMsg{err: err, stats: stats}
+			}
+		}
+
+		return crawlCompleteMsg{stats: stats, files: fileInfos}
+	}
+}
+
+// scanFileInfosCmd pre-scans crawled jobs and reports the total size.
+func scanFileInfosCmd(files []fileInfo, stats *downloadStats, ctx context.Context, opts Options) tea.Cmd {
+	return func() tea.Msg {
+		return scanCompleteMsg{
+			stats:      stats,
+			totalBytes: scanFileInfos(ctx, files, stats, opts.Workers),
+			files:      files,
+		}
+	}
+}
+
+// recursiveDownloadEntries returns the files in the current view, allowed by
+// the profile, and the directories in it to crawl.
+func (m *Model) recursiveDownloadEntries() (files, dirs []fileEntry) {
+	for _, idx := range m.filtered {
+		switch entry := m.entries[idx]; {
+		case entry.Path == "../":
+		case strings.HasSuffix(entry.Path, "/"):
+			dirs = append(dirs, entry)
+		default:
+			files = append(files, entry)
+		}
+	}
+	_, files = m.opts.withProfile(m.currentPath, files)
+	return files, dirs
+}
+
+// startRecursiveDownload crawls dirs and downloads every matching file below
+// them together with files.
+func (m *Model) startRecursiveDownload(files, dirs []fileEntry) tea.Cmd {
+	if m.downloadBusy() {
+		return nil
+	}
+	m.beginDownload(m.optionsFor(m.currentPath), &downloadStats{
+		scanning: true,
+		crawling: true,
+	})
+	m.status = ""
+
+	return tea.Batch(crawlDirectories(m.currentPath, files, dirs, m.entryMatcher(), m.downloadStats, m.ctx, m.jobOpts), tickCmd())
+}
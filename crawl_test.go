@@ -0,0 +1,138 @@
+package myrient_browser
+
+import (
+	"context"
+	"maps"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// crawlPages is a mirror with files at three levels below the root.
+var crawlPages = map[string]string{
+	"/files/":                 `[{"name":"Set","type":"directory"},{"name":"top.zip","type":"file","size":3}]`,
+	"/files/Set/":             `[{"name":"Deep","type":"directory"},{"name":"a.zip","type":"file","size":1},{"name":"notes.txt","type":"file","size":5}]`,
+	"/files/Set/Deep/":        `[{"name":"b+c.zip","type":"file","size":2}]`,
+	"/files/top.zip":          "top",
+	"/files/Set/a.zip":        "a",
+	"/files/Set/notes.txt":    "notes",
+	"/files/Set/Deep/b+c.zip": "bc",
+}
+
+func TestCrawlDirectories(t *testing.T) {
+	srv := httptest.NewServer(mirrorHandler(crawlPages))
+	defer srv.Close()
+
+	onlyZip := func(e fileEntry) bool { return strings.HasSuffix(e.Name, ".zip") }
+	all := func(fileEntry) bool { return true }
+
+	tests := []struct {
+		name     string
+		maxDepth int
+		match    func(fileEntry) bool
+		want     []string
+		dirs     int32
+	}{
+		{"no limit", 0, all, []string{"Set/Deep/b+c.zip", "Set/a.zip", "Set/notes.txt", "top.zip"}, 2},
+		{"one level", 1, all, []string{"Set/a.zip", "Set/notes.txt", "top.zip"}, 2},
+		{"two levels", 2, all, []string{"Set/Deep/b+c.zip", "Set/a.zip", "Set/notes.txt", "top.zip"}, 2},
+		{"filter at every level", 0, onlyZip, []string{"Set/Deep/b+c.zip", "Set/a.zip", "top.zip"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.BaseURL = srv.URL + "/files/"
+			opts.OutputDir = t.TempDir()
+			opts.MaxDepth = tt.maxDepth
+
+			stats := &downloadStats{}
+			files := []fileEntry{{Name: "top.zip", Path: "top.zip", Size: 3}}
+			dirs := []fileEntry{{Name: "Set/", Path: "Set/", Size: -1}}
+			msg, ok := crawlDirectories("", files, dirs, tt.match, stats, context.Background(), opts)().(crawlCompleteMsg)
+			if !ok {
+				t.Fatal("the crawl didn't complete")
+			}
+
+			var got []string
+			for _, f := range msg.files {
+				rel, err := filepath.Rel(opts.OutputDir, f.path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, filepath.ToSlash(rel))
+				if want := opts.BaseURL + filepath.ToSlash(rel); f.url != want {
+					t.Errorf("url = %q, want %q", f.url, want)
+				}
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("jobs = %q, want %q", got, tt.want)
+			}
+			if stats.filesFound != int32(len(tt.want)) {
+				t.Errorf("filesFound = %d, want %d", stats.filesFound, len(tt.want))
+			}
+			if stats.dirsCrawled != tt.dirs {
+				t.Errorf("dirsCrawled = %d, want %d", stats.dirsCrawled, tt.dirs)
+			}
+		})
+	}
+}
+
+func TestCrawlDirectoriesCancelled(t *testing.T) {
+	srv := httptest.NewServer(mirrorHandler(crawlPages))
+	defer srv.Close()
+
+	opts := DefaultOptions()
+	opts.BaseURL = srv.URL + "/files/"
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	dirs := []fileEntry{{Name: "Set/", Path: "Set/", Size: -1}}
+	msg, ok := crawlDirectories("", nil, dirs, func(fileEntry) bool { return true }, &downloadStats{}, ctx, opts)().(crawlCompleteMsg)
+	if !ok {
+		t.Fatal("a cancelled crawl didn't complete")
+	}
+	if len(msg.files) != 0 {
+		t.Errorf("a cancelled crawl returned %d jobs", len(msg.files))
+	}
+}
+
+func TestStartRecursiveDownload(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   map[string]string
+	}{
+		{"", map[string]string{"top.zip": "top", "Set/a.zip": "a", "Set/notes.txt": "notes", "Set/Deep/b+c.zip": "bc"}},
+		{"ext:zip", map[string]string{"top.zip": "top"}},
+		{"zip", map[string]string{"top.zip": "top"}},
+		{"-top", map[string]string{"Set/a.zip": "a", "Set/notes.txt": "notes", "Set/Deep/b+c.zip": "bc"}},
+		{"-notes", map[string]string{"top.zip": "top", "Set/a.zip": "a", "Set/Deep/b+c.zip": "bc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			m := newTestModel(t, crawlPages)
+			m.filterInput.SetValue(tt.filter)
+			m.updateFilter()
+
+			_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("D")})
+			runCmd(m, cmd)
+
+			if got := readFiles(t, m.opts.OutputDir); !maps.Equal(got, tt.want) {
+				t.Errorf("downloaded %v, want %v", got, tt.want)
+			}
+			if m.downloadStats == nil {
+				t.Fatal("the download didn't start")
+			}
+			if n := int(m.downloadStats.total); n != len(tt.want) {
+				t.Errorf("total = %d, want %d", n, len(tt.want))
+			}
+			if n := int(m.downloadStats.filesFound); n != len(tt.want) {
+				t.Errorf("filesFound = %d, want %d", n, len(tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package myrient_browser
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// detailDelay is how long the cursor has to rest on a file before its
+// details are fetched, so scrolling through a listing doesn't send a request
+// for every row passed.
+const detailDelay = 200 * time.Millisecond
+
+// entryDetail is the cached result of a HEAD request for a file.
+type entryDetail struct {
+	loading bool
+	info    remoteInfo
+	err     error
+}
+
+type (
+	detailTickMsg struct {
+		url string
+	}
+	detailLoadedMsg struct {
+		url  string
+		info remoteInfo
+		err  error
+	}
+)
+
+// selectedEntry returns the entry under the cursor.
+func (m *Model) selectedEntry() (fileEntry, bool) {
+	if m.cursor >= len(m.filtered) {
+		return fileEntry{}, false
+	}
+	return m.entries[m.filtered[m.cursor]], true
+}
+
+// entryURL returns the remote URL of entry in the current directory.
+func (m *Model) entryURL(entry fileEntry) string {
+	return m.opts.BaseURL + m.currentPath + entry.Path
+}
+
+// requestDetail schedules fetching the details of the file under the cursor
+// when the detail pane is open and they aren't cached yet.
+func (m *Model) requestDetail() tea.Cmd {
+	if !m.showDetail || m.searching || m.showQueue {
+		return nil
+	}
+	entry, ok := m.selectedEntry()
+	if !ok || strings.HasSuffix(entry.Path, "/") {
+		return nil
+	}
+
+	url := m.entryURL(entry)
+	if m.details[url] != nil || m.detailWanted == url {
+		return nil
+	}
+	m.detailWanted = url
+	return tea.Tick(detailDelay, func(time.Time) tea.Msg {
+		return detailTickMsg{url: url}
+	})
+}
+
+// loadDetail fetches the details of url if the cursor is still on it.
+func (m *Model) loadDetail(url string) tea.Cmd {
+	if m.detailWanted == url {
+		m.detailWanted = ""
+	}
+	entry, ok := m.selectedEntry()
+	if !ok || m.entryURL(entry) != url || m.details[url] != nil {
+		return nil
+	}
+
+	m.details[url] = &entryDetail{loading: true}
+	return func() tea.Msg {
+		info, err := getFileInfo(url)
+		return detailLoadedMsg{url: url, info: info, err: err}
+	}
+}
+
+// detailPaneWidth returns the width of the detail pane for a terminal width.
+func detailPaneWidth(width int) int {
+	return max(width/3, 30)
+}
+
+// detailView renders the detail pane for the entry under the cursor.
+func (m *Model) detailView(width int) string {
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Width(10)
+	valueStyle := lipgloss.NewStyle().Width(max(width-10, 10))
+	s := strings.Builder{}
+	row := func(label, value string) {
+		s.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, labelStyle.Render(label), valueStyle.Render(value)) + "\n")
+	}
+
+	entry, ok := m.selectedEntry()
+	if !ok {
+		return "Nothing selected"
+	}
+	name := strings.TrimSuffix(decodePath(entry.Path), "/")
+	s.WriteString(lipgloss.NewStyle().Bold(true).Width(width).Render(name) + "\n\n")
+
+	url := m.entryURL(entry)
+	row("URL", url)
+	row("Date", formatModTime(entry.ModTime))
+	if strings.HasSuffix(entry.Path, "/") {
+		row("Type", "Directory")
+		return s.String()
+	}
+	row("Size", formatSize(entry.Size))
+
+	s.WriteString("\n")
+	switch detail := m.details[url]; {
+	case detail == nil || detail.loading:
+		row("Remote", "Loading...")
+	case detail.err != nil:
+		row("Remote", lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(detail.err.Error()))
+	default:
+		info := detail.info
+		if !strings.HasPrefix(info.status, "200") {
+			row("Status", lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(info.status))
+		}
+		if info.size >= 0 {
+			row("Length", fmt.Sprintf("%d bytes", info.size))
+		}
+		if info.contentType != "" {
+			row("Type", info.contentType)
+		}
+		row("Modified", formatModTime(info.lastModified))
+		if info.resumable {
+			row("Ranges", "yes - downloads can resume")
+		} else {
+			row("Ranges", "no")
+		}
+	}
+
+	s.WriteString("\n")
+	local := m.local[entry.Path]
+	switch local.state {
+	case localComplete:
+		row("Local", fmt.Sprintf("✓ downloaded (%s)", formatSize(local.size)))
+	case localPartial:
+		row("Local", fmt.Sprintf("partial, %s of %s", formatSize(local.size), formatSize(entry.Size)))
+	case localMismatch:
+		row("Local", fmt.Sprintf("≠ local copy is %s", formatSize(local.size)))
+	default:
+		row("Local", "not downloaded")
+	}
+	row("Saved to", filepath.Join(outputDirFor(m.currentPath, m.optionsFor(m.currentPath)), decodePath(entry.Path)))
+
+	if tags := nameTags(name, true); len(tags) > 0 {
+		s.WriteString("\n")
+		row("Tags", strings.Join(tags, " · "))
+	}
+
+	return s.String()
+}
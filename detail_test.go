@@ -0,0 +1,100 @@
+package myrient_browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// newDetailModel returns a model with the detail pane open on a directory of
+// two files, and the number of HEAD requests made for each file so far.
+func newDetailModel(t *testing.T) (*Model, func(name string) int) {
+	t.Helper()
+	var mu sync.Mutex
+	heads := map[string]int{}
+	pages := mirrorHandler(map[string]string{
+		"/files/":      `[{"name":"a.zip","type":"file","size":4},{"name":"b.zip","type":"file","size":2}]`,
+		"/files/a.zip": "aaaa",
+		"/files/b.zip": "bb",
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			mu.Lock()
+			heads[r.URL.Path]++
+			mu.Unlock()
+		}
+		pages.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	opts := DefaultOptions()
+	opts.BaseURL = srv.URL + "/files/"
+	opts.OutputDir = t.TempDir()
+	m := InitialModel(opts)
+	runCmd(m, m.Init())
+	m.showDetail = true
+
+	return m, func(name string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return heads["/files/"+name]
+	}
+}
+
+func TestDetailDroppedAfterCursorMoves(t *testing.T) {
+	m, heads := newDetailModel(t)
+	a, b := m.opts.BaseURL+"a.zip", m.opts.BaseURL+"b.zip"
+
+	if m.requestDetail() == nil {
+		t.Fatal("resting on a.zip didn't schedule its details")
+	}
+	m.setCursor(1)
+
+	// The delay for a.zip runs out after the cursor has moved on to b.zip.
+	_, cmd := m.Update(detailTickMsg{url: a})
+	runCmd(m, cmd)
+
+	if m.details[a] != nil || heads("a.zip") != 0 {
+		t.Errorf("the stale request for a.zip was fetched: %+v, %d requests", m.details[a], heads("a.zip"))
+	}
+	if d := m.details[b]; d == nil || d.loading || d.info.size != 2 {
+		t.Errorf("details of b.zip = %+v, want them loaded", d)
+	}
+	if heads("b.zip") != 1 {
+		t.Errorf("b.zip was fetched %d times, want once", heads("b.zip"))
+	}
+}
+
+func TestDetailCached(t *testing.T) {
+	m, heads := newDetailModel(t)
+	a := m.opts.BaseURL + "a.zip"
+
+	runCmd(m, m.requestDetail())
+	if d := m.details[a]; d == nil || d.loading || d.info.size != 4 {
+		t.Fatalf("details of a.zip = %+v, want them loaded", d)
+	}
+
+	m.setCursor(1)
+	m.setCursor(0)
+	if cmd := m.requestDetail(); cmd != nil {
+		t.Error("returning to a.zip scheduled its details again")
+	}
+	if cmd := m.loadDetail(a); cmd != nil {
+		t.Error("a cached detail was fetched again")
+	}
+	if heads("a.zip") != 1 {
+		t.Errorf("a.zip was fetched %d times, want once", heads("a.zip"))
+	}
+}
+
+func TestDetailRequestedOnce(t *testing.T) {
+	m, _ := newDetailModel(t)
+
+	if m.requestDetail() == nil {
+		t.Fatal("resting on a.zip didn't schedule its details")
+	}
+	if m.requestDetail() != nil {
+		t.Error("a second request for a.zip was scheduled while the first was pending")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -16,15 +17,24 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-func getFileInfo(fileURL string) (size int64, resumable bool, err error) {
+// remoteInfo is what a HEAD request tells about a file.
+type remoteInfo struct {
+	status       string
+	size         int64
+	resumable    bool
+	contentType  string
+	lastModified time.Time
+}
+
+func getFileInfo(fileURL string) (info remoteInfo, err error) {
 	req, err := http.NewRequest("HEAD", fileURL, nil)
 	if err != nil {
-		return 0, false, err
+		return info, err
 	}
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return 0, false, err
+		return info, err
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil && err == nil {
@@ -32,274 +42,313 @@ func getFileInfo(fileURL string) (size int64, resumable bool, err error) {
 		}
 	}()
 
-	size = resp.ContentLength
-	resumable = resp.Header.Get("Accept-Ranges") == "bytes"
-	return size, resumable, nil
+	info.status = resp.Status
+	info.size = resp.ContentLength
+	info.resumable = resp.Header.Get("Accept-Ranges") == "bytes"
+	info.contentType = resp.Header.Get("Content-Type")
+	if t, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.lastModified = t
+	}
+	return info, nil
 }
 
-func scanAndDownload(basePath string, files []fileEntry, stats *downloadStats, ctx context.Context) tea.Cmd {
+func scanAndDownload(basePath string, files []fileEntry, stats *downloadStats, ctx context.Context, opts Options) tea.Cmd {
 	return func() tea.Msg {
-		decodedBasePath, err := url.QueryUnescape(basePath)
+		fileInfos, totalBytes, err := scanFiles(ctx, basePath, files, stats, opts)
 		if err != nil {
-			decodedBasePath = basePath
-		}
-
-		outputDir := filepath.Join("./downloads", decodedBasePath)
-		if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
-			return errMsg{err: fmt.Errorf("failed to create directory: %w", err)}
-		}
-
-		var fileInfos []fileInfo
-		var totalBytes int64
-		var mu sync.Mutex
-
-		jobs := make(chan fileEntry, len(files))
-		results := make(chan fileInfo, len(files))
-		var wg sync.WaitGroup
-
-		for w := 0; w < numWorkers; w++ {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				for file := range jobs {
-					select {
-					case <-ctx.Done():
-						return
-					default:
-					}
-
-					decodedFilename, err := url.QueryUnescape(file.Path)
-					if err != nil {
-						decodedFilename = file.Path
-					}
-
-					fileURL := baseURL + basePath + file.Path
-					outputPath := filepath.Join(outputDir, decodedFilename)
-					size, resumable, err := getFileInfo(fileURL)
-
-					existingSize := int64(0)
-					if stat, err := os.Stat(outputPath); err == nil {
-						existingSize = stat.Size()
-					} else if stat, err := os.Stat(outputPath + ".part"); err == nil {
-						existingSize = stat.Size()
-					}
-
-					info := fileInfo{
-						url:       fileURL,
-						filename:  decodedFilename,
-						path:      outputPath,
-						size:      size,
-						resumable: resumable,
-					}
-
-					if err == nil && size > 0 {
-						mu.Lock()
-						totalBytes += size - existingSize
-						mu.Unlock()
-					}
-
-					results <- info
-					atomic.AddInt32(&stats.scanProgress, 1)
-				}
-			}()
-		}
-
-		for _, file := range files {
-			jobs <- file
-		}
-		close(jobs)
-
-		go func() {
-			wg.Wait()
-			close(results)
-		}()
-
-		for info := range results {
-			fileInfos = append(fileInfos, info)
+			return errMsg{err: err, stats: stats}
 		}
 
 		return scanCompleteMsg{
+			stats:      stats,
 			totalBytes: totalBytes,
 			files:      fileInfos,
 		}
 	}
 }
 
-func startDownloadWithFiles(files []fileInfo, stats *downloadStats, ctx context.Context, autoExtract bool, extractToFolder bool, deleteZip bool) tea.Cmd {
-	return func() tea.Msg {
-		jobs := make(chan fileInfo, len(files))
-		var wg sync.WaitGroup
-		var extractFiles []string
-		var extractMu sync.Mutex
-
-		for w := 0; w < numWorkers; w++ {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				for job := range jobs {
-					select {
-					case <-ctx.Done():
-						return
-					default:
-					}
+// scanFiles looks up the size of every file with a HEAD request and returns
+// the download jobs along with the number of bytes still to be fetched.
+func scanFiles(ctx context.Context, basePath string, files []fileEntry, stats *downloadStats, opts Options) ([]fileInfo, int64, error) {
+	outputDir, err := prepareOutputDir(basePath, opts)
+	if err != nil {
+		return nil, 0, err
+	}
 
-					for atomic.LoadInt32(&stats.paused) == 1 {
-						time.Sleep(100 * time.Millisecond)
-						select {
-						case <-ctx.Done():
-							return
-						default:
-						}
-					}
+	fileInfos := make([]fileInfo, len(files))
+	for i, file := range files {
+		if fileInfos[i], err = newFileInfo(basePath, outputDir, file, opts); err != nil {
+			return nil, 0, err
+		}
+	}
 
-					downloadFileWithResume(ctx, job, stats)
-					atomic.AddInt32(&stats.completed, 1)
+	return fileInfos, scanFileInfos(ctx, fileInfos, stats, opts.Workers), nil
+}
 
-					if autoExtract && strings.HasSuffix(strings.ToLower(job.path), ".zip") {
-						extractMu.Lock()
-						extractFiles = append(extractFiles, job.path)
-						extractMu.Unlock()
-					}
-				}
-			}()
-		}
+// scanFileInfos fills in the size of each job and returns the number of
+// bytes still to be fetched.
+func scanFileInfos(ctx context.Context, fileInfos []fileInfo, stats *downloadStats, workers int) int64 {
+	headFiles(ctx, fileInfos, stats, workers)
 
-		for _, file := range files {
-			jobs <- file
+	var totalBytes int64
+	for _, info := range fileInfos {
+		if info.size > 0 {
+			totalBytes += info.size - localSize(info.path)
 		}
-		close(jobs)
-		wg.Wait()
+	}
+	return totalBytes
+}
+
+// headFiles fills in the size and resumability of each file in place using
+// HEAD requests spread over workers goroutines. Files whose request fails are
+// left with a zero size.
+func headFiles(ctx context.Context, files []fileInfo, stats *downloadStats, workers int) {
+	jobs := make(chan int, len(files))
+	var wg sync.WaitGroup
 
-		if autoExtract && len(extractFiles) > 0 {
-			for _, zipPath := range extractFiles {
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
 				select {
 				case <-ctx.Done():
-					return downloadCompleteMsg{}
+					return
 				default:
 				}
 
-				var extractDir string
-				if extractToFolder {
-					extractDir = strings.TrimSuffix(zipPath, filepath.Ext(zipPath))
-				} else {
-					extractDir = filepath.Dir(zipPath)
-				}
-
-				if err := unzipFile(zipPath, extractDir, deleteZip); err != nil {
-					return errMsg{err: fmt.Errorf("failed to extract %s: %w", filepath.Base(zipPath), err)}
+				info, err := getFileInfo(files[i].url)
+				if err == nil {
+					files[i].size = info.size
+					files[i].resumable = info.resumable
+					if info.size > 0 {
+						atomic.AddInt64(&stats.scanBytes, info.size)
+					}
 				}
-				atomic.AddInt32(&stats.extracted, 1)
+				atomic.AddInt32(&stats.scanProgress, 1)
 			}
-		}
+		}()
+	}
 
-		return downloadCompleteMsg{}
+	for i := range files {
+		jobs <- i
 	}
+	close(jobs)
+	wg.Wait()
 }
 
-func downloadAllFiles(basePath string, files []fileEntry, stats *downloadStats, ctx context.Context, autoExtract bool, extractToFolder bool, deleteZip bool) tea.Cmd {
+// localSize returns the size of the downloaded file at path, or of its .part
+// file if the download is incomplete.
+func localSize(path string) int64 {
+	if stat, err := os.Stat(path); err == nil {
+		return stat.Size()
+	}
+	if stat, err := os.Stat(path + ".part"); err == nil {
+		return stat.Size()
+	}
+	return 0
+}
+
+// outputDirFor returns the local directory that mirrors basePath.
+func outputDirFor(basePath string, opts Options) string {
+	decodedBasePath, err := url.PathUnescape(basePath)
+	if err != nil {
+		decodedBasePath = basePath
+	}
+	return filepath.Join(opts.OutputDir, decodedBasePath)
+}
+
+// prepareOutputDir creates the local directory that mirrors basePath.
+func prepareOutputDir(basePath string, opts Options) (string, error) {
+	outputDir := outputDirFor(basePath, opts)
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+	return outputDir, nil
+}
+
+// newFileInfo builds an unscanned download job for file in basePath. A file
+// whose name would be saved outside outputDir is an error.
+func newFileInfo(basePath, outputDir string, file fileEntry, opts Options) (fileInfo, error) {
+	decodedFilename, err := url.PathUnescape(file.Path)
+	if err != nil {
+		decodedFilename = file.Path
+	}
+	if !filepath.IsLocal(decodedFilename) {
+		return fileInfo{}, fmt.Errorf("remote file %q is outside %s", decodedFilename, decodePath(basePath))
+	}
+
+	return fileInfo{
+		url:             opts.BaseURL + basePath + file.Path,
+		filename:        decodedFilename,
+		path:            filepath.Join(outputDir, decodedFilename),
+		size:            0,
+		resumable:       true,
+		extract:         opts.AutoExtract,
+		extractToFolder: opts.ExtractToFolder,
+		deleteZip:       opts.DeleteZip,
+	}, nil
+}
+
+func startDownloadWithFiles(files []fileInfo, stats *downloadStats, ctx context.Context, opts Options) tea.Cmd {
+	stats.files = newFileProgress(files)
 	return func() tea.Msg {
-		decodedBasePath, err := url.QueryUnescape(basePath)
-		if err != nil {
-			decodedBasePath = basePath
+		if err := runDownloads(ctx, files, stats, opts, nil); err != nil {
+			return errMsg{err: err, stats: stats}
 		}
+		return downloadCompleteMsg{stats: stats}
+	}
+}
 
-		outputDir := filepath.Join("./downloads", decodedBasePath)
-		if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
-			return errMsg{err: fmt.Errorf("failed to create directory: %w", err)}
+func downloadAllFiles(basePath string, files []fileEntry, stats *downloadStats, ctx context.Context, opts Options) tea.Cmd {
+	stats.files = make([]fileProgress, len(files))
+	for i, file := range files {
+		stats.files[i] = fileProgress{name: decodePath(file.Path), size: max(file.Size, 0)}
+	}
+	return func() tea.Msg {
+		outputDir, err := prepareOutputDir(basePath, opts)
+		if err != nil {
+			return errMsg{err: err, stats: stats}
 		}
 
 		var fileInfos []fileInfo
 		for _, file := range files {
-			decodedFilename, err := url.QueryUnescape(file.Path)
+			info, err := newFileInfo(basePath, outputDir, file, opts)
 			if err != nil {
-				decodedFilename = file.Path
+				return errMsg{err: err, stats: stats}
 			}
+			fileInfos = append(fileInfos, info)
+		}
 
-			fileInfos = append(fileInfos, fileInfo{
-				url:       baseURL + basePath + file.Path,
-				filename:  decodedFilename,
-				path:      filepath.Join(outputDir, decodedFilename),
-				size:      0,
-				resumable: true,
-			})
+		if err := runDownloads(ctx, fileInfos, stats, opts, nil); err != nil {
+			return errMsg{err: err, stats: stats}
 		}
+		return downloadCompleteMsg{stats: stats}
+	}
+}
 
-		jobs := make(chan fileInfo, len(fileInfos))
-		var wg sync.WaitGroup
-		var extractFiles []string
-		var extractMu sync.Mutex
+// newFileProgress returns the initial progress of each of files.
+func newFileProgress(files []fileInfo) []fileProgress {
+	progress := make([]fileProgress, len(files))
+	for i, file := range files {
+		progress[i] = fileProgress{name: file.filename, size: file.size}
+	}
+	return progress
+}
+
+// downloadResult describes how a single download job ended.
+type downloadResult struct {
+	file    fileInfo
+	bytes   int64
+	skipped bool
+	err     error
+}
+
+// runDownloads downloads files with opts.Workers workers and then extracts
+// the zip files whose jobs ask for it. report, when not nil, is called from the
+// workers as each job finishes. The progress of each job is tracked in
+// stats.files, which callers that display it allocate beforehand.
+func runDownloads(ctx context.Context, files []fileInfo, stats *downloadStats, opts Options, report func(downloadResult)) error {
+	if len(stats.files) != len(files) {
+		stats.files = newFileProgress(files)
+	}
 
-		for w := 0; w < numWorkers; w++ {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				for job := range jobs {
+	jobs := make(chan int, len(files))
+	var wg sync.WaitGroup
+	var extractFiles []fileInfo
+	var extractMu sync.Mutex
+
+	for w := 0; w < opts.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				job := files[i]
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+
+				for atomic.LoadInt32(&stats.paused) == 1 {
+					time.Sleep(100 * time.Millisecond)
 					select {
 					case <-ctx.Done():
 						return
 					default:
 					}
-
-					for atomic.LoadInt32(&stats.paused) == 1 {
-						time.Sleep(100 * time.Millisecond)
-						select {
-						case <-ctx.Done():
-							return
-						default:
-						}
-					}
-
-					downloadFileWithResume(ctx, job, stats)
-					atomic.AddInt32(&stats.completed, 1)
-
-					if autoExtract && strings.HasSuffix(strings.ToLower(job.path), ".zip") {
-						extractMu.Lock()
-						extractFiles = append(extractFiles, job.path)
-						extractMu.Unlock()
-					}
 				}
-			}()
-		}
-
-		for _, file := range fileInfos {
-			jobs <- file
-		}
-		close(jobs)
-		wg.Wait()
 
-		if autoExtract && len(extractFiles) > 0 {
-			for _, zipPath := range extractFiles {
-				select {
-				case <-ctx.Done():
-					return downloadCompleteMsg{}
+				progress := &stats.files[i]
+				atomic.StoreInt32(&progress.status, fileActive)
+				n, skipped, err := downloadFileWithResume(ctx, job, stats, progress)
+				switch {
+				case err != nil:
+					atomic.AddInt32(&stats.failed, 1)
+					atomic.StoreInt32(&progress.status, fileFailed)
+				case skipped:
+					atomic.AddInt32(&stats.skipped, 1)
+					atomic.StoreInt32(&progress.status, fileSkipped)
 				default:
+					atomic.StoreInt32(&progress.status, fileDone)
 				}
+				atomic.StoreInt64(&progress.finished, time.Now().UnixNano())
+				atomic.AddInt32(&stats.completed, 1)
 
-				var extractDir string
-				if extractToFolder {
-					extractDir = strings.TrimSuffix(zipPath, filepath.Ext(zipPath))
-				} else {
-					extractDir = filepath.Dir(zipPath)
+				if report != nil {
+					report(downloadResult{file: job, bytes: n, skipped: skipped, err: err})
 				}
 
-				if err := unzipFile(zipPath, extractDir, deleteZip); err != nil {
-					return errMsg{err: fmt.Errorf("failed to extract %s: %w", filepath.Base(zipPath), err)}
+				if err == nil && job.extract && strings.HasSuffix(strings.ToLower(job.path), ".zip") {
+					extractMu.Lock()
+					extractFiles = append(extractFiles, job)
+					extractMu.Unlock()
 				}
-				atomic.AddInt32(&stats.extracted, 1)
 			}
+		}()
+	}
+
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	atomic.StoreInt32(&stats.toExtract, int32(len(extractFiles)))
+	for _, job := range extractFiles {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
 		}
 
-		return downloadCompleteMsg{}
+		var extractDir string
+		if job.extractToFolder {
+			extractDir = strings.TrimSuffix(job.path, filepath.Ext(job.path))
+		} else {
+			extractDir = filepath.Dir(job.path)
+		}
+
+		if err := unzipFile(job.path, extractDir, job.deleteZip); err != nil {
+			return fmt.Errorf("failed to extract %s: %w", filepath.Base(job.path), err)
+		}
+		atomic.AddInt32(&stats.extracted, 1)
 	}
+
+	return nil
 }
 
-func downloadFileWithResume(ctx context.Context, file fileInfo, stats *downloadStats) int64 {
+// downloadFileWithResume downloads file, continuing from its .part file when
+// the server supports ranges. skipped is true when the file was already
+// complete on disk. The bytes and size of the file are recorded in progress.
+func downloadFileWithResume(ctx context.Context, file fileInfo, stats *downloadStats, progress *fileProgress) (n int64, skipped bool, err error) {
 	partFile := file.path + ".part"
 	existingSize := int64(0)
 
 	if stat, err := os.Stat(file.path); err == nil {
 		if file.size > 0 && stat.Size() == file.size {
-			return 0
+			atomic.StoreInt64(&progress.bytes, file.size)
+			return 0, true, nil
 		}
 	}
 
@@ -307,9 +356,13 @@ func downloadFileWithResume(ctx context.Context, file fileInfo, stats *downloadS
 		existingSize = stat.Size()
 	}
 
+	if err := os.MkdirAll(filepath.Dir(file.path), os.ModePerm); err != nil {
+		return 0, false, err
+	}
+
 	req, err := http.NewRequestWithContext(ctx, "GET", file.url, nil)
 	if err != nil {
-		return 0
+		return 0, false, err
 	}
 
 	if existingSize > 0 && file.resumable {
@@ -318,45 +371,97 @@ func downloadFileWithResume(ctx context.Context, file fileInfo, stats *downloadS
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return 0
+		return 0, false, err
 	}
 	defer func() { _ = resp.Body.Close() }()
 
-	flag := os.O_CREATE | os.O_WRONLY
-	if existingSize > 0 && (resp.StatusCode == 206 || resp.StatusCode == 200) {
-		flag |= os.O_APPEND
+	// The range starts past the end of the file: the .part either holds the
+	// whole file already, or it is stale and the download starts over.
+	if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && existingSize > 0 {
+		size := file.size
+		if size <= 0 {
+			size = contentRangeSize(resp.Header.Get("Content-Range"))
+		}
+		if size == existingSize {
+			atomic.StoreInt64(&progress.bytes, size)
+			atomic.StoreInt64(&progress.size, size)
+			return 0, false, os.Rename(partFile, file.path)
+		}
+		if err := os.Remove(partFile); err != nil {
+			return 0, false, err
+		}
+		return downloadFileWithResume(ctx, file, stats, progress)
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return 0, false, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	// Only append when the server honoured the range request, otherwise the
+	// body is the whole file again.
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	resumed := int64(0)
+	if existingSize > 0 && resp.StatusCode == http.StatusPartialContent {
+		flag = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		resumed = existingSize
+	}
+	atomic.StoreInt64(&progress.bytes, resumed)
+	if resp.ContentLength > 0 {
+		atomic.StoreInt64(&progress.size, resumed+resp.ContentLength)
 	}
 
 	out, err := os.OpenFile(partFile, flag, 0o644)
 	if err != nil {
-		return 0
+		return 0, false, err
 	}
 	defer func() { _ = out.Close() }()
 
 	reader := &progressReader{
 		reader: resp.Body,
 		stats:  stats,
+		file:   progress,
 	}
 
-	n, _ := io.Copy(out, reader)
+	n, err = io.Copy(out, reader)
+	if err != nil {
+		return n, false, err
+	}
 
 	select {
 	case <-ctx.Done():
-		return n
+		return n, false, ctx.Err()
 	default:
 	}
 
-	_ = os.Rename(partFile, file.path)
-	return n
+	if err := out.Close(); err != nil {
+		return n, false, err
+	}
+	return n, false, os.Rename(partFile, file.path)
+}
+
+// contentRangeSize returns the complete length given by a Content-Range
+// header such as "bytes */1234", or -1 if it is unknown.
+func contentRangeSize(header string) int64 {
+	_, total, ok := strings.Cut(header, "/")
+	if !ok {
+		return -1
+	}
+	size, err := strconv.ParseInt(total, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return size
 }
 
 type progressReader struct {
 	reader io.Reader
 	stats  *downloadStats
+	file   *fileProgress
 }
 
 func (pr *progressReader) Read(p []byte) (int, error) {
 	n, err := pr.reader.Read(p)
 	atomic.AddInt64(&pr.stats.bytesDownload, int64(n))
+	atomic.AddInt64(&pr.file.bytes, int64(n))
 	return n, err
 }
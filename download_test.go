@@ -0,0 +1,88 @@
+package myrient_browser
+
+import (
+	"context"
+	"maps"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestDownloadFileWithResume(t *testing.T) {
+	tests := []struct {
+		name  string
+		size  int64
+		local map[string]string
+		n     int64
+	}{
+		{"new", 4, nil, 4},
+		{"resumed", 4, map[string]string{"a.zip.part": "aa"}, 2},
+		{"complete part", 4, map[string]string{"a.zip.part": "aaaa"}, 0},
+		{"complete part of unknown size", 0, map[string]string{"a.zip.part": "aaaa"}, 0},
+		{"part larger than the file", 4, map[string]string{"a.zip.part": "aaaaaa"}, 4},
+	}
+
+	srv := httptest.NewServer(mirrorHandler(map[string]string{"/a.zip": "aaaa"}))
+	defer srv.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.local)
+			file := fileInfo{url: srv.URL + "/a.zip", filename: "a.zip", path: filepath.Join(dir, "a.zip"), size: tt.size, resumable: true}
+			progress := &fileProgress{}
+
+			n, skipped, err := downloadFileWithResume(context.Background(), file, &downloadStats{}, progress)
+			if err != nil {
+				t.Fatalf("download failed: %v", err)
+			}
+			if n != tt.n || skipped {
+				t.Errorf("download = %d bytes, skipped %v, want %d bytes", n, skipped, tt.n)
+			}
+			if progress.bytes != 4 {
+				t.Errorf("progress = %d bytes, want 4", progress.bytes)
+			}
+			if got, want := readFiles(t, dir), map[string]string{"a.zip": "aaaa"}; !maps.Equal(got, want) {
+				t.Errorf("files = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestNewFileInfo(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"a.zip", "a.zip"},
+		{"A+B%20%28Europe%29.zip", "A+B (Europe).zip"},
+		{"Sub%20Dir/a.zip", filepath.Join("Sub Dir", "a.zip")},
+		{"..", ""},
+		{"../a.zip", ""},
+		{"..%2F..%2Fa.zip", ""},
+		{"%2Fetc%2Fpasswd", ""},
+	}
+	opts := DefaultOptions()
+	opts.BaseURL = "https://example.org/files/"
+	outputDir := filepath.Join("out", "Set")
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			info, err := newFileInfo("Set/", outputDir, fileEntry{Path: tt.path}, opts)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("newFileInfo saves %q to %q, want an error", tt.path, info.path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newFileInfo failed: %v", err)
+			}
+			if want := filepath.Join(outputDir, tt.want); info.path != want {
+				t.Errorf("path = %q, want %q", info.path, want)
+			}
+			if want := opts.BaseURL + "Set/" + tt.path; info.url != want {
+				t.Errorf("url = %q, want %q", info.url, want)
+			}
+		})
+	}
+}
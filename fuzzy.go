@@ -0,0 +1,131 @@
+package myrient_browser
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Scores awarded by fuzzyMatch for each matched character.
+const (
+	fuzzyCharScore      = 1
+	fuzzyWordStartBonus = 8
+	fuzzyConsecutive    = 5
+	fuzzyGapPenalty     = 1
+	fuzzyMaxGapPenalty  = 10
+)
+
+// fuzzyMatch matches the words of query against name in any order. Each
+// word must occur in name as a subsequence of its characters; matches at the
+// start of words and runs of consecutive characters score higher. positions
+// are the indexes of the matched runes of name.
+func fuzzyMatch(name, query string) (score int, positions []int, ok bool) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return 0, nil, true
+	}
+
+	runes := []rune(strings.ToLower(name))
+	for _, term := range terms {
+		termScore, termPositions, found := bestSubsequence(runes, []rune(term))
+		if !found {
+			return 0, nil, false
+		}
+		score += termScore
+		positions = append(positions, termPositions...)
+	}
+
+	slices.Sort(positions)
+	return score, slices.Compact(positions), true
+}
+
+// bestSubsequence finds the highest scoring way to match term as a
+// subsequence of name, trying every occurrence of its first rune as the start.
+func bestSubsequence(name, term []rune) (int, []int, bool) {
+	best, found := 0, false
+	var bestPositions []int
+
+	for start, r := range name {
+		if r != term[0] {
+			continue
+		}
+		score, positions, ok := greedySubsequence(name, term, start)
+		if ok && (!found || score > best) {
+			best, bestPositions, found = score, positions, true
+		}
+	}
+	return best, bestPositions, found
+}
+
+// greedySubsequence matches term in name from start, preferring the next
+// word start over an earlier mid-word character for each rune.
+func greedySubsequence(name, term []rune, start int) (int, []int, bool) {
+	positions := make([]int, 0, len(term))
+	score := 0
+	i := start
+
+	for t, r := range term {
+		j := -1
+		if t == 0 {
+			j = start
+		} else if i < len(name) && name[i] == r {
+			j = i
+		} else {
+			for k := i; k < len(name); k++ {
+				if name[k] != r {
+					continue
+				}
+				if j < 0 {
+					j = k
+				}
+				if isWordStart(name, k) {
+					j = k
+					break
+				}
+			}
+		}
+		if j < 0 {
+			return 0, nil, false
+		}
+
+		score += fuzzyCharScore
+		if isWordStart(name, j) {
+			score += fuzzyWordStartBonus
+		}
+		if t > 0 {
+			if gap := j - positions[t-1] - 1; gap == 0 {
+				score += fuzzyConsecutive
+			} else {
+				score -= min(gap*fuzzyGapPenalty, fuzzyMaxGapPenalty)
+			}
+		}
+		positions = append(positions, j)
+		i = j + 1
+	}
+	return score, positions, true
+}
+
+func isWordStart(name []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev := name[i-1]
+	return !unicode.IsLetter(prev) && !unicode.IsDigit(prev)
+}
+
+// substringMatch reports whether name contains query, ignoring case, and the
+// positions of the runes of its first occurrence.
+func substringMatch(name, query string) ([]int, bool) {
+	lower := strings.ToLower(name)
+	i := strings.Index(lower, strings.ToLower(query))
+	if i < 0 {
+		return nil, false
+	}
+
+	start := len([]rune(lower[:i]))
+	positions := make([]int, len([]rune(strings.ToLower(query))))
+	for k := range positions {
+		positions[k] = start + k
+	}
+	return positions, true
+}
@@ -0,0 +1,100 @@
+package myrient_browser
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		ok        bool
+		positions []int
+	}{
+		{"Super Mario Bros", "", true, nil},
+		{"Super Mario Bros", "smb", true, []int{0, 6, 12}},
+		{"Super Mario Bros", "SMB", true, []int{0, 6, 12}},
+		{"Super Mario Bros", "mario", true, []int{6, 7, 8, 9, 10}},
+		{"Super Mario Bros", "bros super", true, []int{0, 1, 2, 3, 4, 12, 13, 14, 15}},
+		{"Super Mario Bros", "mb", true, []int{6, 12}},
+		{"Super Mario Bros", "bm", false, nil},
+		{"Super Mario Bros", "zelda", false, nil},
+		{"Super Mario Bros", "mario zelda", false, nil},
+		{"Pokémon Red", "pkmn", true, []int{0, 2, 4, 6}},
+		{"Pokémon Red", "émon", true, []int{3, 4, 5, 6}},
+		{"banana", "ana", true, []int{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+"/"+tt.query, func(t *testing.T) {
+			_, positions, ok := fuzzyMatch(tt.name, tt.query)
+			if ok != tt.ok {
+				t.Fatalf("fuzzyMatch(%q, %q) ok = %v, want %v", tt.name, tt.query, ok, tt.ok)
+			}
+			if !slices.Equal(positions, tt.positions) {
+				t.Errorf("fuzzyMatch(%q, %q) positions = %v, want %v", tt.name, tt.query, positions, tt.positions)
+			}
+		})
+	}
+}
+
+func TestFuzzyMatchRanking(t *testing.T) {
+	tests := []struct {
+		query         string
+		better, worse string
+	}{
+		// Word starts beat characters in the middle of words.
+		{"mk", "Mario Kart", "Smoking"},
+		{"sm", "Super Mario", "Plasma"},
+		// Consecutive characters beat scattered ones.
+		{"kart", "Mario Kart DS", "Kick And Run Tennis"},
+		{"zel", "Zelda", "Zombie Elevator"},
+		// Smaller gaps beat larger ones.
+		{"ab", "axb", "axxxxxb"},
+		// The best occurrence counts, not the first.
+		{"ds", "Dreams - Mario Kart DS", "Dreams"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			better, _, ok := fuzzyMatch(tt.better, tt.query)
+			if !ok {
+				t.Fatalf("fuzzyMatch(%q, %q) doesn't match", tt.better, tt.query)
+			}
+			worse, _, ok := fuzzyMatch(tt.worse, tt.query)
+			if !ok {
+				t.Fatalf("fuzzyMatch(%q, %q) doesn't match", tt.worse, tt.query)
+			}
+			if better <= worse {
+				t.Errorf("%q scores %d and %q scores %d for %q, want the first higher",
+					tt.better, better, tt.worse, worse, tt.query)
+			}
+		})
+	}
+}
+
+func TestSubstringMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		ok        bool
+		positions []int
+	}{
+		{"Mario Kart", "kart", true, []int{6, 7, 8, 9}},
+		{"Mario Kart", "KART", true, []int{6, 7, 8, 9}},
+		{"Mario Kart", "mk", false, nil},
+		{"Pokémon", "MON", true, []int{4, 5, 6}},
+		{"Pokémon", "kém", true, []int{2, 3, 4}},
+		{"abab", "ab", true, []int{0, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+"/"+tt.query, func(t *testing.T) {
+			positions, ok := substringMatch(tt.name, tt.query)
+			if ok != tt.ok {
+				t.Fatalf("substringMatch(%q, %q) ok = %v, want %v", tt.name, tt.query, ok, tt.ok)
+			}
+			if !slices.Equal(positions, tt.positions) {
+				t.Errorf("substringMatch(%q, %q) positions = %v, want %v", tt.name, tt.query, positions, tt.positions)
+			}
+		})
+	}
+}
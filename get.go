@@ -0,0 +1,224 @@
+package myrient_browser
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// GetOptions controls a headless download.
+type GetOptions struct {
+	// Match is a glob pattern applied to file names.
+	Match string
+	// Progress receives line-based progress output. It may be nil.
+	Progress io.Writer
+	// Interval is how often an aggregate progress line is written. Zero
+	// disables periodic progress lines.
+	Interval time.Duration
+}
+
+// Summary reports the outcome of a headless download.
+type Summary struct {
+	OK       int
+	Failed   int
+	Skipped  int
+	Deleted  int
+	Bytes    int64
+	Duration time.Duration
+	Failures []string
+	// Plan lists the changes a dry run would have made.
+	Plan []Change
+}
+
+// Change is a single step of a sync plan.
+type Change struct {
+	// Action is "new" or "changed" for a download and "delete" for a
+	// removal.
+	Action string
+	// Path is slash-separated and relative to the local directory.
+	Path string
+	// Size is the remote size of a download in bytes.
+	Size int64
+}
+
+// Get downloads the files in the remote directory at location, or the single
+// file it points to, using the same scan, download and extraction pipeline as
+// the TUI.
+func Get(ctx context.Context, opts Options, location string, g GetOptions) (Summary, error) {
+	var summary Summary
+
+	basePath, files, err := resolveFiles(opts, location, g.Match)
+	if err != nil {
+		return summary, err
+	}
+
+	logf := progressLogger(g.Progress)
+	opts, files = opts.withProfile(basePath, files)
+
+	if len(files) == 0 {
+		logf("no files to download in %s", decodePath(basePath))
+		return summary, nil
+	}
+
+	start := time.Now()
+	stats := &downloadStats{
+		total:    int32(len(files)),
+		scanning: !opts.SkipScan,
+	}
+
+	var infos []fileInfo
+	if opts.SkipScan {
+		outputDir, err := prepareOutputDir(basePath, opts)
+		if err != nil {
+			return summary, err
+		}
+		for _, file := range files {
+			info, err := newFileInfo(basePath, outputDir, file, opts)
+			if err != nil {
+				return summary, err
+			}
+			infos = append(infos, info)
+		}
+	} else {
+		logf("scanning %d files", len(files))
+		var totalBytes int64
+		infos, totalBytes, err = scanFiles(ctx, basePath, files, stats, opts)
+		if err != nil {
+			return summary, err
+		}
+		stats.scanning = false
+		stats.bytesTotal = totalBytes
+		logf("scan complete: %.2f MB to download", float64(totalBytes)/1024/1024)
+	}
+
+	err = downloadWithProgress(ctx, infos, stats, opts, &summary, logf, g.Interval)
+	summary.Duration = time.Since(start)
+
+	if err != nil {
+		return summary, err
+	}
+	if extracted := atomic.LoadInt32(&stats.extracted); extracted > 0 {
+		logf("extracted %d archives", extracted)
+	}
+	return summary, ctx.Err()
+}
+
+// downloadWithProgress runs the downloads for the headless subcommands,
+// recording each outcome in summary and logging a line per file plus an
+// aggregate line every interval.
+func downloadWithProgress(ctx context.Context, infos []fileInfo, stats *downloadStats, opts Options, summary *Summary, logf func(string, ...any), interval time.Duration) error {
+	start := time.Now()
+	done := make(chan struct{})
+	if interval > 0 {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					bytesDownload := atomic.LoadInt64(&stats.bytesDownload)
+					speed := float64(bytesDownload) / time.Since(start).Seconds() / 1024 / 1024
+					logf("progress %d/%d files, %.2f MB, %.2f MB/s",
+						atomic.LoadInt32(&stats.completed), stats.total, float64(bytesDownload)/1024/1024, speed)
+				}
+			}
+		}()
+	}
+
+	var mu sync.Mutex
+	err := runDownloads(ctx, infos, stats, opts, func(r downloadResult) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		n := atomic.LoadInt32(&stats.completed)
+		switch {
+		case r.err != nil:
+			summary.Failed++
+			summary.Failures = append(summary.Failures, r.file.filename)
+			logf("[%d/%d] failed %s: %v", n, stats.total, r.file.filename, r.err)
+		case r.skipped:
+			summary.Skipped++
+			logf("[%d/%d] skipped %s (already downloaded)", n, stats.total, r.file.filename)
+		default:
+			summary.OK++
+			logf("[%d/%d] ok %s (%.2f MB)", n, stats.total, r.file.filename, float64(r.bytes)/1024/1024)
+		}
+	})
+	close(done)
+
+	summary.Bytes += atomic.LoadInt64(&stats.bytesDownload)
+	return err
+}
+
+// resolveFiles returns the escaped directory path and the files to download
+// for location. If location is not a directory it is looked up as a file in
+// its parent directory.
+func resolveFiles(opts Options, location, match string) (string, []fileEntry, error) {
+	basePath, err := ResolvePath(opts.BaseURL, location)
+	if err != nil {
+		return "", nil, err
+	}
+
+	entries, err := fetchDirectory(opts, basePath)
+	if err != nil {
+		if strings.HasSuffix(location, "/") || basePath == "" {
+			return "", nil, err
+		}
+
+		parent, name := splitPath(basePath)
+		parentEntries, parentErr := fetchDirectory(opts, parent)
+		if parentErr != nil {
+			return "", nil, err
+		}
+		for _, entry := range parentEntries {
+			if decodePath(entry.Path) == decodePath(name) {
+				return parent, []fileEntry{entry}, nil
+			}
+		}
+		return "", nil, err
+	}
+
+	var files []fileEntry
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Path, "/") {
+			continue
+		}
+		if match != "" {
+			ok, err := filepath.Match(match, entry.Name)
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid match pattern %q: %w", match, err)
+			}
+			if !ok {
+				continue
+			}
+		}
+		files = append(files, entry)
+	}
+
+	return basePath, files, nil
+}
+
+// splitPath splits an escaped directory path such as "a/b/" into its parent
+// "a/" and last segment "b".
+func splitPath(path string) (parent, name string) {
+	path = strings.TrimSuffix(path, "/")
+	i := strings.LastIndex(path, "/")
+	return path[:i+1], path[i+1:]
+}
+
+// progressLogger returns a printf-style function writing lines to w, or
+// discarding them when w is nil.
+func progressLogger(w io.Writer) func(string, ...any) {
+	return func(format string, args ...any) {
+		if w != nil {
+			_, _ = fmt.Fprintf(w, format+"\n", args...)
+		}
+	}
+}
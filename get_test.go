@@ -0,0 +1,102 @@
+package myrient_browser
+
+import (
+	"context"
+	"maps"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	srv := httptest.NewServer(mirrorHandler(map[string]string{
+		"/files/Games/":       `[{"name":"a.zip","type":"file","size":4},{"name":"b.zip","type":"file","size":2},{"name":"c.txt","type":"file","size":1}]`,
+		"/files/Games/a.zip":  "aaaa",
+		"/files/Games/b.zip":  "bb",
+		"/files/Games/c.txt":  "c",
+		"/files/Broken/":      `[{"name":"a.zip","type":"file","size":4},{"name":"gone.zip","type":"file","size":4}]`,
+		"/files/Broken/a.zip": "aaaa",
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		location string
+		match    string
+		skipScan bool
+		local    map[string]string
+		want     Summary
+		after    map[string]string
+	}{
+		{
+			name:     "directory",
+			location: "Games",
+			want:     Summary{OK: 3, Bytes: 7},
+			after:    map[string]string{"Games/a.zip": "aaaa", "Games/b.zip": "bb", "Games/c.txt": "c"},
+		},
+		{
+			name:     "without scan",
+			location: "Games/",
+			skipScan: true,
+			want:     Summary{OK: 3, Bytes: 7},
+			after:    map[string]string{"Games/a.zip": "aaaa", "Games/b.zip": "bb", "Games/c.txt": "c"},
+		},
+		{
+			name:     "match",
+			location: "Games",
+			match:    "*.zip",
+			want:     Summary{OK: 2, Bytes: 6},
+			after:    map[string]string{"Games/a.zip": "aaaa", "Games/b.zip": "bb"},
+		},
+		{
+			name:     "single file",
+			location: "Games/b.zip",
+			want:     Summary{OK: 1, Bytes: 2},
+			after:    map[string]string{"Games/b.zip": "bb"},
+		},
+		{
+			name:     "already downloaded",
+			location: "Games",
+			match:    "*.zip",
+			local:    map[string]string{"Games/a.zip": "aaaa"},
+			want:     Summary{OK: 1, Skipped: 1, Bytes: 2},
+			after:    map[string]string{"Games/a.zip": "aaaa", "Games/b.zip": "bb"},
+		},
+		{
+			name:     "failed file",
+			location: "Broken",
+			skipScan: true,
+			want:     Summary{OK: 1, Failed: 1, Bytes: 4, Failures: []string{"gone.zip"}},
+			after:    map[string]string{"Broken/a.zip": "aaaa"},
+		},
+		{
+			name:     "nothing matches",
+			location: "Games",
+			match:    "*.iso",
+			after:    map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.local)
+
+			opts := DefaultOptions()
+			opts.BaseURL = srv.URL + "/files/"
+			opts.OutputDir = dir
+			opts.SkipScan = tt.skipScan
+
+			summary, err := Get(context.Background(), opts, tt.location, GetOptions{Match: tt.match})
+			if err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+			if summary.OK != tt.want.OK || summary.Failed != tt.want.Failed || summary.Skipped != tt.want.Skipped ||
+				summary.Bytes != tt.want.Bytes || !slices.Equal(summary.Failures, tt.want.Failures) {
+				t.Errorf("summary = %+v, want %+v", summary, tt.want)
+			}
+			if got := readFiles(t, dir); !maps.Equal(got, tt.after) {
+				t.Errorf("files = %v, want %v", got, tt.after)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package myrient_browser
+
+import (
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxHistory is how many visited directories are remembered for going back
+// and forward.
+const maxHistory = 100
+
+// historyEntry is a visited directory and the view it was left in, so going
+// back or forward to it returns to the same spot.
+type historyEntry struct {
+	path   string
+	cursor int
+	offset int
+	filter string
+}
+
+// navigation is a directory being opened. It only takes effect once the
+// directory has loaded, so a failed load leaves the listing, the current path
+// and the history as they were.
+type navigation struct {
+	path string
+	// restore is the view to return to, if any.
+	restore *savedState
+	// commit updates the history once the directory has loaded, before the
+	// current path changes.
+	commit func()
+}
+
+// recordView stores the view of the current directory in its history entry.
+func (m *Model) recordView() {
+	if m.historyIndex >= len(m.history) {
+		return
+	}
+	entry := &m.history[m.historyIndex]
+	entry.cursor, entry.offset, entry.filter = m.cursor, m.viewport.offset, m.filterInput.Value()
+}
+
+// open starts loading the escaped directory path, replacing any navigation
+// still in progress.
+func (m *Model) open(path string, restore *savedState, commit func()) tea.Cmd {
+	m.pending = &navigation{path: path, restore: restore, commit: commit}
+	m.status = ""
+	return loadDirectory(m.opts, path, m.cache, false)
+}
+
+// opened makes the pending navigation to path current, if there is one.
+func (m *Model) opened(path string) bool {
+	nav := m.pending
+	if nav == nil || nav.path != path {
+		return false
+	}
+	m.pending = nil
+	nav.commit()
+	m.currentPath = nav.path
+	if nav.restore != nil {
+		m.restore = nav.restore
+	}
+	return true
+}
+
+// openFailed reports that location couldn't be opened and stays where it
+// was. If nothing has been shown yet the root is opened instead.
+func (m *Model) openFailed(location string, err error) tea.Cmd {
+	m.lastError = err.Error()
+	m.jumpTo = ""
+	if len(m.history) > 0 || m.pending != nil {
+		return nil
+	}
+	m.restore = nil
+	if location == "" {
+		return nil
+	}
+	return m.visit("")
+}
+
+// visit opens the escaped directory path as a new step in the history,
+// dropping anything that could have been gone forward to.
+func (m *Model) visit(path string) tea.Cmd {
+	return m.open(path, nil, func() {
+		switch {
+		case len(m.history) == 0:
+			m.history = []historyEntry{{path: path}}
+			m.historyIndex = 0
+		case path != m.currentPath:
+			m.recordView()
+			m.history = append(m.history[:m.historyIndex+1], historyEntry{path: path})
+			if len(m.history) > maxHistory {
+				m.history = slices.Delete(m.history, 0, len(m.history)-maxHistory)
+			}
+			m.historyIndex = len(m.history) - 1
+		}
+	})
+}
+
+// goUp visits the parent of the current directory with the cursor on the
+// directory it came from.
+func (m *Model) goUp() tea.Cmd {
+	if m.currentPath == "" {
+		return nil
+	}
+	m.jumpTo = childName(m.currentPath)
+	return m.visit(parentPath(m.currentPath))
+}
+
+// back returns to the previous directory in the history. With nothing to go
+// back to it goes up to the parent instead, which can be gone forward from.
+func (m *Model) back() tea.Cmd {
+	if m.historyIndex > 0 {
+		return m.reopen(m.historyIndex - 1)
+	}
+	if m.currentPath == "" {
+		return nil
+	}
+
+	m.jumpTo = childName(m.currentPath)
+	return m.open(parentPath(m.currentPath), nil, func() {
+		m.recordView()
+		m.history = slices.Insert(m.history, 0, historyEntry{path: parentPath(m.currentPath)})
+		if len(m.history) > maxHistory {
+			m.history = m.history[:maxHistory]
+		}
+		m.historyIndex = 0
+	})
+}
+
+// forward goes to the next directory in the history after going back.
+func (m *Model) forward() tea.Cmd {
+	if m.historyIndex >= len(m.history)-1 {
+		return nil
+	}
+	return m.reopen(m.historyIndex + 1)
+}
+
+// reopen loads the directory at history position i and restores the view it
+// was left in once it has loaded.
+func (m *Model) reopen(i int) tea.Cmd {
+	entry := m.history[i]
+	restore := &savedState{Cursor: entry.cursor, Offset: entry.offset, Filter: entry.filter}
+	return m.open(entry.path, restore, func() {
+		m.recordView()
+		m.historyIndex = i
+	})
+}
+
+// parentPath returns the parent of the escaped directory path.
+func parentPath(path string) string {
+	trimmed := strings.TrimSuffix(path, "/")
+	return trimmed[:strings.LastIndex(trimmed, "/")+1]
+}
+
+// childName returns the last segment of the escaped directory path, as it
+// appears in its parent's listing.
+func childName(path string) string {
+	return strings.TrimPrefix(path, parentPath(path))
+}
@@ -0,0 +1,113 @@
+package myrient_browser
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+var historyPages = map[string]string{
+	"/files/":     `[{"name":"A","type":"directory"},{"name":"B","type":"directory"}]`,
+	"/files/A/":   `[{"name":"C","type":"directory"},{"name":"a.zip","type":"file","size":1}]`,
+	"/files/A/C/": `[{"name":"c.zip","type":"file","size":1}]`,
+	"/files/B/":   `[{"name":"b.zip","type":"file","size":1}]`,
+}
+
+func TestHistory(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   []string
+		path    string
+		history []string
+		index   int
+		failed  bool
+	}{
+		{"start", nil, "", []string{""}, 0, false},
+		{"visit", []string{"A/", "A/C/"}, "A/C/", []string{"", "A/", "A/C/"}, 2, false},
+		{"back", []string{"A/", "A/C/", "back"}, "A/", []string{"", "A/", "A/C/"}, 1, false},
+		{"back twice", []string{"A/", "A/C/", "back", "back"}, "", []string{"", "A/", "A/C/"}, 0, false},
+		{"forward", []string{"A/", "A/C/", "back", "back", "forward"}, "A/", []string{"", "A/", "A/C/"}, 1, false},
+		{"forward at the end", []string{"A/", "forward"}, "A/", []string{"", "A/"}, 1, false},
+		{"visit drops forward", []string{"A/", "A/C/", "back", "back", "B/"}, "B/", []string{"", "B/"}, 1, false},
+		{"up", []string{"A/", "A/C/", "up"}, "A/", []string{"", "A/", "A/C/", "A/"}, 3, false},
+		{"back past the start", []string{"start A/C/", "back"}, "A/", []string{"A/", "A/C/"}, 0, false},
+		{"failed visit", []string{"A/", "typo/"}, "A/", []string{"", "A/"}, 1, true},
+		{"back across a failed visit", []string{"A/", "typo/", "back"}, "", []string{"", "A/"}, 0, false},
+		{"forward across a failed visit", []string{"A/", "back", "typo/", "forward"}, "A/", []string{"", "A/"}, 1, false},
+		{"failed back", []string{"A/", "B/", "forget A/", "back"}, "B/", []string{"", "gone/", "B/"}, 2, true},
+		{"failed forward", []string{"A/", "back", "forget A/", "forward"}, "", []string{"", "gone/"}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel(t, historyPages)
+			for _, step := range tt.steps {
+				m.lastError = ""
+				if path, ok := strings.CutPrefix(step, "start "); ok {
+					m.history, m.historyIndex = nil, 0
+					runCmd(m, m.visit(path))
+					continue
+				}
+				if path, ok := strings.CutPrefix(step, "forget "); ok {
+					// The directory disappeared from the mirror.
+					i := slices.IndexFunc(m.history, func(e historyEntry) bool { return e.path == path })
+					m.history[i].path = "gone/"
+					continue
+				}
+				switch step {
+				case "back":
+					runCmd(m, m.back())
+				case "forward":
+					runCmd(m, m.forward())
+				case "up":
+					runCmd(m, m.goUp())
+				default:
+					runCmd(m, m.visit(step))
+				}
+			}
+
+			if m.currentPath != tt.path {
+				t.Errorf("current path = %q, want %q", m.currentPath, tt.path)
+			}
+			var history []string
+			for _, e := range m.history {
+				history = append(history, e.path)
+			}
+			if !slices.Equal(history, tt.history) || m.historyIndex != tt.index {
+				t.Errorf("history = %q at %d, want %q at %d", history, m.historyIndex, tt.history, tt.index)
+			}
+			if failed := m.lastError != ""; failed != tt.failed {
+				t.Errorf("error = %q, want failed %v", m.lastError, tt.failed)
+			}
+			if m.pending != nil {
+				t.Errorf("navigation to %q still pending", m.pending.path)
+			}
+		})
+	}
+}
+
+func TestHistoryRestoresView(t *testing.T) {
+	m := newTestModel(t, historyPages)
+	runCmd(m, m.visit("A/"))
+	m.setCursor(2)
+	runCmd(m, m.visit("typo/"))
+	if m.lastError == "" {
+		t.Fatal("visiting a missing directory didn't fail")
+	}
+	if name := m.entries[m.filtered[m.cursor]].Name; name != "a.zip" {
+		t.Fatalf("cursor on %q after the failed visit, want a.zip", name)
+	}
+
+	runCmd(m, m.visit("B/"))
+	runCmd(m, m.back())
+	if m.currentPath != "A/" {
+		t.Fatalf("back went to %q, want A/", m.currentPath)
+	}
+	if name := m.entries[m.filtered[m.cursor]].Name; name != "a.zip" {
+		t.Errorf("cursor on %q after going back, want a.zip", name)
+	}
+
+	runCmd(m, m.back())
+	if name := m.entries[m.filtered[m.cursor]].Name; name != "A/" {
+		t.Errorf("cursor on %q in the root, want A/", name)
+	}
+}
@@ -0,0 +1,328 @@
+package myrient_browser
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	defaultCrawlDelay = 500 * time.Millisecond
+	indexFileName     = "index.json.gz"
+	indexFileVersion  = 1
+	// indexCheckpoint is how many directories are crawled between saves,
+	// so that an interrupted crawl can resume where it left off.
+	indexCheckpoint = 50
+	// maxCrawlFailures is how many directories in a row may fail to load
+	// before the crawl gives up, and maxCrawlAttempts how often a single
+	// directory is tried before it is left out of the index.
+	maxCrawlFailures = 5
+	maxCrawlAttempts = 3
+	// indexQuitTimeout is how long quitting waits for a running crawl to
+	// save its progress. If it runs out the last checkpoint is kept.
+	indexQuitTimeout = 2 * time.Second
+)
+
+// indexEntry is a file or directory in the search index along with the
+// escaped path of the directory it was listed in.
+type indexEntry struct {
+	Dir string `json:"dir"`
+	fileEntry
+
+	// lower is the lowercased decoded path of the entry and nameAt the
+	// offset of its name in it; both are used for matching.
+	lower  string
+	nameAt int
+}
+
+func newIndexEntry(dir string, entry fileEntry) indexEntry {
+	e := indexEntry{Dir: dir, fileEntry: entry}
+	e.prepare()
+	return e
+}
+
+func (e *indexEntry) prepare() {
+	e.lower = strings.ToLower(decodePath(e.Dir) + strings.TrimSuffix(e.Name, "/"))
+	e.nameAt = len(e.lower) - len(strings.ToLower(strings.TrimSuffix(e.Name, "/")))
+}
+
+// isDir reports whether the entry is a directory.
+func (e *indexEntry) isDir() bool {
+	return strings.HasSuffix(e.Path, "/")
+}
+
+// searchIndex is a persistent listing of every file and directory under a
+// base URL.
+type searchIndex struct {
+	Version  int       `json:"version"`
+	BaseURL  string    `json:"base_url"`
+	Updated  time.Time `json:"updated"`
+	Complete bool      `json:"complete"`
+	Dirs     int       `json:"dirs"`
+	// Pending lists the directories still to be crawled while the index
+	// is incomplete.
+	Pending []string     `json:"pending,omitempty"`
+	Entries []indexEntry `json:"entries"`
+}
+
+// DefaultIndexPath returns the location of the search index, under the
+// user's cache directory.
+func DefaultIndexPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, appName, indexFileName)
+}
+
+// loadIndex reads the search index at path. It returns nil if there is no
+// index for baseURL.
+func loadIndex(path, baseURL string) (*searchIndex, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read search index %s: %w", path, err)
+	}
+
+	var idx searchIndex
+	if err := json.NewDecoder(zr).Decode(&idx); err != nil {
+		return nil, fmt.Errorf("failed to read search index %s: %w", path, err)
+	}
+	if idx.Version != indexFileVersion || idx.BaseURL != baseURL {
+		return nil, nil
+	}
+
+	for i := range idx.Entries {
+		idx.Entries[i].prepare()
+	}
+	return &idx, nil
+}
+
+// writeIndex atomically replaces the search index at path.
+func writeIndex(path string, idx *searchIndex) error {
+	if path == "" {
+		return nil
+	}
+
+	idx.Version = indexFileVersion
+	return writeFileAtomic(path, func(w io.Writer) error {
+		zw := gzip.NewWriter(w)
+		if err := json.NewEncoder(zw).Encode(idx); err != nil {
+			return err
+		}
+		return zw.Close()
+	})
+}
+
+type (
+	indexLoadedMsg struct {
+		index *searchIndex
+	}
+	// indexProgressMsg reports a running crawl. entries is a snapshot of
+	// everything found so far.
+	indexProgressMsg struct {
+		ix      *indexer
+		dirs    int
+		pending int
+		entries []indexEntry
+	}
+	indexDoneMsg struct {
+		ix    *indexer
+		index *searchIndex
+		err   error
+	}
+)
+
+func loadIndexCmd(path, baseURL string) tea.Cmd {
+	return func() tea.Msg {
+		idx, err := loadIndex(path, baseURL)
+		if err != nil {
+			return nil
+		}
+		return indexLoadedMsg{index: idx}
+	}
+}
+
+// indexer crawls the whole mirror in the background to build a search index.
+type indexer struct {
+	cancel   context.CancelFunc
+	progress chan indexProgressMsg
+	done     chan struct{}
+
+	// result and err are set before done is closed.
+	result *searchIndex
+	err    error
+
+	// stopping is set by the model once it has cancelled the crawl and is
+	// waiting for the partial index to be saved.
+	stopping bool
+}
+
+// startIndexer starts crawling from the root of opts.BaseURL, or resumes the
+// crawl recorded in prev if it was interrupted. Listings are taken from cache
+// while they are fresh; every directory fetched from the server is followed
+// by a pause of opts.CrawlDelay.
+func startIndexer(opts Options, cache *listingCache, prev *searchIndex) *indexer {
+	ctx, cancel := context.WithCancel(context.Background())
+	ix := &indexer{
+		cancel:   cancel,
+		progress: make(chan indexProgressMsg, 1),
+		done:     make(chan struct{}),
+	}
+
+	idx := &searchIndex{BaseURL: opts.BaseURL, Pending: []string{""}}
+	if prev != nil && !prev.Complete && len(prev.Pending) > 0 {
+		idx.Dirs = prev.Dirs
+		idx.Pending = slices.Clone(prev.Pending)
+		idx.Entries = slices.Clip(prev.Entries)
+	}
+
+	go func() {
+		defer close(ix.done)
+		ix.err = ix.crawl(ctx, opts, cache, idx)
+
+		idx.Complete = len(idx.Pending) == 0
+		if idx.Complete {
+			idx.Pending = nil
+		}
+		idx.Updated = time.Now()
+		if err := writeIndex(opts.IndexPath, idx); err != nil && ix.err == nil {
+			ix.err = err
+		}
+		ix.result = idx
+	}()
+
+	return ix
+}
+
+func (ix *indexer) crawl(ctx context.Context, opts Options, cache *listingCache, idx *searchIndex) error {
+	failures := 0
+	attempts := map[string]int{}
+	for len(idx.Pending) > 0 {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		dir := idx.Pending[0]
+		entries, fetched, err := crawlListing(opts, cache, dir)
+		if err != nil {
+			failures++
+			if failures >= maxCrawlFailures {
+				return err
+			}
+			idx.Pending = idx.Pending[1:]
+			attempts[dir]++
+			if attempts[dir] < maxCrawlAttempts {
+				// Retry later rather than leaving a hole in the index.
+				idx.Pending = append(idx.Pending, dir)
+			}
+		} else {
+			failures = 0
+			idx.Pending = idx.Pending[1:]
+			for _, entry := range entries {
+				if entry.Path == "../" {
+					continue
+				}
+				idx.Entries = append(idx.Entries, newIndexEntry(dir, entry))
+				if strings.HasSuffix(entry.Path, "/") {
+					idx.Pending = append(idx.Pending, dir+entry.Path)
+				}
+			}
+			idx.Dirs++
+
+			if idx.Dirs%indexCheckpoint == 0 {
+				idx.Updated = time.Now()
+				_ = writeIndex(opts.IndexPath, idx)
+			}
+		}
+
+		// Drop the update if the previous one has not been picked up yet.
+		select {
+		case ix.progress <- indexProgressMsg{ix: ix, dirs: idx.Dirs, pending: len(idx.Pending), entries: slices.Clip(idx.Entries)}:
+		default:
+		}
+
+		if fetched || err != nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(opts.CrawlDelay):
+			}
+		}
+	}
+	return nil
+}
+
+// crawlListing returns the entries of dir, from the listing cache when it
+// holds a fresh copy. fetched reports whether the server was contacted.
+func crawlListing(opts Options, cache *listingCache, dir string) (entries []fileEntry, fetched bool, err error) {
+	cached, _ := cache.get(opts.BaseURL, dir)
+	if cached != nil && !cache.stale(cached) {
+		return cached.Entries, false, nil
+	}
+
+	l, notModified, err := fetchListing(opts, dir, cached)
+	if err != nil {
+		return nil, true, err
+	}
+	if notModified {
+		l.Fetched = time.Now()
+	}
+	_ = cache.put(l)
+	return l.Entries, true, nil
+}
+
+// wait returns a command that delivers the next progress update, or the
+// final result once the crawl has ended.
+func (ix *indexer) wait() tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case msg := <-ix.progress:
+			return msg
+		case <-ix.done:
+			return indexDoneMsg{ix: ix, index: ix.result, err: ix.err}
+		}
+	}
+}
+
+// pauseIndexer cancels a running crawl without waiting for it. The indexer
+// stays in place until its indexDoneMsg delivers the partial index.
+func (m *Model) pauseIndexer() {
+	m.indexer.cancel()
+	m.indexer.stopping = true
+}
+
+// abandonIndexer stops a running crawl on quit without waiting longer than
+// indexQuitTimeout for it to save its progress. The index is written to a
+// temporary file first, so a save cut short leaves the last checkpoint.
+func (m *Model) abandonIndexer() {
+	if m.indexer != nil {
+		m.indexer.cancel()
+		select {
+		case <-m.indexer.done:
+		case <-time.After(indexQuitTimeout):
+		}
+		m.indexer = nil
+	}
+}
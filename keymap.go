@@ -0,0 +1,376 @@
+package myrient_browser
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keyMap holds the key bindings of the browser, the search screen, the queue
+// and bookmark panels and the download screen. Prompts, where keys are typed
+// as text, keep their fixed Enter, Esc and Tab keys.
+type keyMap struct {
+	Up, Down, PageUp, PageDown, Home, End key.Binding
+	Open, Back, Forward                   key.Binding
+	Filter, Sort, GoTo, Search, Index     key.Binding
+	Refresh                               key.Binding
+
+	SearchUp, SearchDown, SearchPageUp, SearchPageDown   key.Binding
+	SearchOpen, SearchQueue, SearchDownload, SearchClose key.Binding
+
+	Download, DownloadRecursive, Queue, QueuePanel key.Binding
+	Help, Quit, ForceQuit                          key.Binding
+
+	Bookmark, Bookmarks key.Binding
+
+	Mark, MarkAll, InvertMarks, ClearMarks, DownloadMarked key.Binding
+
+	PreScan, Extract, Folder, DeleteZip, FilterMode, MissingOnly, Details key.Binding
+
+	Progress, Pause, Resume, Cancel key.Binding
+
+	Close, MoveItemUp, MoveItemDown, Remove, ClearFinished, Rename key.Binding
+
+	// prefixes holds the first keys of sequences such as "g g".
+	prefixes map[string]bool
+}
+
+// keyAction is a bindable action: its name in the config file, the
+// description shown in help and its keys in the default keymap.
+type keyAction struct {
+	name    string
+	desc    string
+	binding *key.Binding
+	keys    []string
+}
+
+// actions lists the bindable actions in the order the help overlay shows
+// them.
+func (k *keyMap) actions() []keyAction {
+	return []keyAction{
+		{"up", "Up", &k.Up, []string{"up"}},
+		{"down", "Down", &k.Down, []string{"down"}},
+		{"page_up", "Page up", &k.PageUp, []string{"pgup"}},
+		{"page_down", "Page down", &k.PageDown, []string{"pgdown"}},
+		{"home", "First entry", &k.Home, []string{"home"}},
+		{"end", "Last entry", &k.End, []string{"end"}},
+		{"open", "Open", &k.Open, []string{"enter", "right"}},
+		{"back", "Back", &k.Back, []string{"left", "[", "alt+left"}},
+		{"forward", "Forward", &k.Forward, []string{"]", "alt+right"}},
+		{"filter", "Filter", &k.Filter, []string{"/"}},
+		{"sort", "Sort", &k.Sort, []string{"o"}},
+		{"goto", "Go to", &k.GoTo, []string{"g"}},
+		{"search", "Search", &k.Search, []string{"S"}},
+		{"index", "Index", &k.Index, []string{"I"}},
+		{"refresh", "Refresh", &k.Refresh, []string{"R"}},
+
+		{"search_up", "Up", &k.SearchUp, []string{"up"}},
+		{"search_down", "Down", &k.SearchDown, []string{"down"}},
+		{"search_page_up", "Page up", &k.SearchPageUp, []string{"pgup"}},
+		{"search_page_down", "Page down", &k.SearchPageDown, []string{"pgdown"}},
+		{"search_open", "Open", &k.SearchOpen, []string{"enter"}},
+		{"search_queue", "Queue", &k.SearchQueue, []string{"tab"}},
+		{"search_download", "Download queue", &k.SearchDownload, []string{"ctrl+d"}},
+		{"search_close", "Close", &k.SearchClose, []string{"esc"}},
+
+		{"download", "Download all", &k.Download, []string{"d"}},
+		{"download_recursive", "Recursive", &k.DownloadRecursive, []string{"D"}},
+		{"queue", "Queue", &k.Queue, []string{"+"}},
+		{"queue_panel", "Queue panel", &k.QueuePanel, []string{"Q"}},
+		{"help", "Help", &k.Help, []string{"?"}},
+		{"quit", "Quit", &k.Quit, []string{"q"}},
+		{"force_quit", "Quit, even while downloading", &k.ForceQuit, []string{"ctrl+c"}},
+
+		{"bookmark", "Add", &k.Bookmark, []string{"b"}},
+		{"bookmarks", "Open", &k.Bookmarks, []string{"B"}},
+
+		{"mark", "Mark", &k.Mark, []string{"space"}},
+		{"mark_all", "All", &k.MarkAll, []string{"a"}},
+		{"invert_marks", "Invert", &k.InvertMarks, []string{"i"}},
+		{"clear_marks", "Clear", &k.ClearMarks, []string{"u"}},
+		{"download_marked", "Download marked", &k.DownloadMarked, []string{"m"}},
+
+		{"pre_scan", "PreScan", &k.PreScan, []string{"s"}},
+		{"extract", "Extract", &k.Extract, []string{"x"}},
+		{"folder", "Folder", &k.Folder, []string{"f"}},
+		{"delete_zip", "Delete Zip", &k.DeleteZip, []string{"z"}},
+		{"filter_mode", "Filter mode", &k.FilterMode, []string{"F"}},
+		{"missing_only", "Missing only", &k.MissingOnly, []string{"M"}},
+		{"details", "Details", &k.Details, []string{"tab"}},
+
+		{"progress", "Progress", &k.Progress, []string{"v"}},
+		{"pause", "Pause", &k.Pause, []string{"p"}},
+		{"resume", "Resume", &k.Resume, []string{"r"}},
+		{"cancel", "Cancel paused download or scan", &k.Cancel, []string{"esc"}},
+
+		{"close", "Close", &k.Close, []string{"esc"}},
+		{"move_item_up", "Move item up", &k.MoveItemUp, []string{"K", "shift+up"}},
+		{"move_item_down", "Move item down", &k.MoveItemDown, []string{"J", "shift+down"}},
+		{"remove", "Remove", &k.Remove, []string{"x", "delete"}},
+		{"clear_finished", "Clear finished", &k.ClearFinished, []string{"C"}},
+		{"rename", "Rename bookmark", &k.Rename, []string{"r"}},
+	}
+}
+
+// keymapPresets replace the default keys of some actions.
+var keymapPresets = map[string]map[string][]string{
+	"default": {},
+	"vim": {
+		"up":        {"k", "up"},
+		"down":      {"j", "down"},
+		"page_up":   {"ctrl+b", "pgup"},
+		"page_down": {"ctrl+f", "pgdown"},
+		"home":      {"g g", "home"},
+		"end":       {"G", "end"},
+		"open":      {"enter", "l", "right"},
+		"back":      {"h", "left", "[", "alt+left"},
+		"goto":      {":"},
+	},
+}
+
+// browserActions are the actions of the listing. They have to be bound to
+// different keys; the other screens may reuse them.
+var browserActions = []string{
+	"up", "down", "page_up", "page_down", "home", "end", "open", "back", "forward",
+	"filter", "sort", "goto", "search", "index", "refresh",
+	"download", "download_recursive", "queue", "queue_panel", "help", "quit", "force_quit",
+	"bookmark", "bookmarks",
+	"mark", "mark_all", "invert_marks", "clear_marks", "download_marked",
+	"pre_scan", "extract", "folder", "delete_zip", "filter_mode", "missing_only", "details",
+	"progress", "pause", "resume",
+}
+
+// searchActions are the actions of the search screen, which have to be bound
+// to different keys too. Any other key is typed into the query.
+var searchActions = []string{
+	"search_up", "search_down", "search_page_up", "search_page_down",
+	"search_open", "search_queue", "search_download", "search_close", "force_quit",
+}
+
+// bookmarkActions, queueActions and progressActions are the actions of the
+// bookmark panel, the queue panel and the progress screen, including the
+// listing keys that still work there.
+var (
+	bookmarkActions = []string{
+		"quit", "force_quit", "close", "bookmarks", "up", "down", "open", "rename", "remove",
+	}
+	queueActions = []string{
+		"quit", "force_quit", "close", "queue_panel", "up", "down", "move_item_up", "move_item_down",
+		"remove", "clear_finished", "open", "progress", "pause", "resume",
+	}
+	progressActions = []string{
+		"force_quit", "progress", "up", "down", "page_up", "page_down", "home", "pause", "resume", "cancel",
+	}
+)
+
+// keyScreens are the sets of actions that are active together, each of
+// which has to be bound to different keys.
+var keyScreens = [][]string{browserActions, searchActions, bookmarkActions, queueActions, progressActions}
+
+// newKeyMap builds the keymap from a preset ("default" or "vim", empty
+// meaning default) and overrides keyed by action name. An override replaces
+// all keys of its action, and an empty list unbinds it.
+func newKeyMap(preset string, overrides map[string][]string) (keyMap, error) {
+	if preset == "" {
+		preset = "default"
+	}
+	presetKeys, ok := keymapPresets[preset]
+	if !ok {
+		return keyMap{}, fmt.Errorf("unknown keymap %q (want default or vim)", preset)
+	}
+
+	var k keyMap
+	actions := k.actions()
+	for name := range overrides {
+		if !slices.ContainsFunc(actions, func(a keyAction) bool { return a.name == name }) {
+			return keyMap{}, fmt.Errorf("unknown key action %q", name)
+		}
+	}
+
+	bound := make([]map[string]string, len(keyScreens))
+	for i := range bound {
+		bound[i] = map[string]string{}
+	}
+	claim := func(bound map[string]string, kk, name string) error {
+		if other, ok := bound[kk]; ok {
+			return fmt.Errorf("key %q is bound to both %s and %s", keyLabel(kk), other, name)
+		}
+		bound[kk] = name
+		return nil
+	}
+	k.prefixes = map[string]bool{}
+	for _, a := range actions {
+		keys := a.keys
+		if preset, ok := presetKeys[a.name]; ok {
+			keys = preset
+		}
+		if override, ok := overrides[a.name]; ok {
+			keys = override
+		}
+
+		keys = slices.Clone(keys)
+		for i, kk := range keys {
+			if kk == "space" {
+				keys[i] = " "
+			}
+			if first, _, ok := strings.Cut(keys[i], " "); ok && keys[i] != " " {
+				k.prefixes[first] = true
+			}
+			for s, screen := range keyScreens {
+				if !slices.Contains(screen, a.name) {
+					continue
+				}
+				if err := claim(bound[s], keys[i], a.name); err != nil {
+					return keyMap{}, err
+				}
+			}
+		}
+
+		*a.binding = key.NewBinding(key.WithKeys(keys...), key.WithHelp(keysLabel(keys), a.desc))
+		if len(keys) == 0 {
+			a.binding.SetEnabled(false)
+		}
+	}
+
+	for _, screen := range bound {
+		for kk, name := range screen {
+			if k.prefixes[kk] {
+				return keyMap{}, fmt.Errorf("key %q is bound to %s but also starts a key sequence", keyLabel(kk), name)
+			}
+		}
+	}
+	return k, nil
+}
+
+// keyPress is a key, or a sequence of keys separated by spaces, matched
+// against bindings.
+type keyPress string
+
+func (k keyPress) String() string { return string(k) }
+
+// resolveKey returns the key press to match bindings against, joining msg to
+// the first key of a sequence typed before it. It returns false when msg
+// starts a sequence and the next key is needed.
+func (m *Model) resolveKey(msg tea.KeyMsg) (keyPress, bool) {
+	k := msg.String()
+	if m.keyPending != "" {
+		k = m.keyPending + " " + k
+		m.keyPending = ""
+		return keyPress(k), true
+	}
+	if m.keys.prefixes[k] {
+		m.keyPending = k
+		return "", false
+	}
+	return keyPress(k), true
+}
+
+// keyNames are the labels of keys whose names don't read well in help.
+var keyNames = map[string]string{
+	"up":     "↑",
+	"down":   "↓",
+	"left":   "←",
+	"right":  "→",
+	"pgup":   "PgUp",
+	"pgdown": "PgDn",
+	"home":   "Home",
+	"end":    "End",
+	"enter":  "Enter",
+	"esc":    "Esc",
+	"tab":    "Tab",
+	" ":      "Space",
+	"delete": "Del",
+}
+
+// keyLabel returns how a key is shown in help, e.g. "Ctrl+C" for "ctrl+c"
+// and "gg" for the sequence "g g".
+func keyLabel(k string) string {
+	if name, ok := keyNames[k]; ok {
+		return name
+	}
+	if k != " " && strings.Contains(k, " ") {
+		return strings.ReplaceAll(k, " ", "")
+	}
+
+	parts := strings.Split(k, "+")
+	if len(parts) == 1 || slices.Contains(parts, "") {
+		return k
+	}
+	for i, p := range parts[:len(parts)-1] {
+		parts[i] = strings.ToUpper(p[:1]) + p[1:]
+	}
+	last := parts[len(parts)-1]
+	if name, ok := keyNames[last]; ok {
+		last = name
+	} else {
+		last = strings.ToUpper(last)
+	}
+	parts[len(parts)-1] = last
+	return strings.Join(parts, "+")
+}
+
+// keysLabel returns how all keys of a binding are shown in help.
+func keysLabel(keys []string) string {
+	labels := make([]string, len(keys))
+	for i, k := range keys {
+		labels[i] = keyLabel(k)
+	}
+	return strings.Join(labels, "/")
+}
+
+// hint renders bindings as a footer entry such as "[↑/↓] Move", showing the
+// first key of each. Without a description the first binding's is used.
+// Unbound actions are left out.
+func hint(desc string, bindings ...key.Binding) string {
+	var labels []string
+	for _, b := range bindings {
+		if b.Enabled() && len(b.Keys()) > 0 {
+			labels = append(labels, keyLabel(b.Keys()[0]))
+		}
+	}
+	if len(labels) == 0 {
+		return ""
+	}
+	if desc == "" {
+		desc = bindings[0].Help().Desc
+	}
+	return "[" + strings.Join(labels, "/") + "] " + desc
+}
+
+// keyHint renders the first key of b for status messages, such as "[I]".
+func keyHint(b key.Binding) string {
+	if len(b.Keys()) == 0 {
+		return "[unbound]"
+	}
+	return "[" + keyLabel(b.Keys()[0]) + "]"
+}
+
+// hints joins footer entries, skipping empty ones.
+func hints(entries ...string) string {
+	return strings.Join(slices.DeleteFunc(entries, func(e string) bool { return e == "" }), " ")
+}
+
+// keyGroup is a titled set of bindings in the help overlay.
+type keyGroup struct {
+	title    string
+	bindings []key.Binding
+}
+
+// groups returns the bindings shown in the help overlay.
+func (k keyMap) groups() []keyGroup {
+	return []keyGroup{
+		{"Navigation", []key.Binding{k.Up, k.Down, k.PageUp, k.PageDown, k.Home, k.End, k.Open, k.Back, k.Forward,
+			k.Filter, k.Sort, k.GoTo, k.Search, k.Index, k.Refresh}},
+		{"Search", []key.Binding{k.SearchUp, k.SearchDown, k.SearchPageUp, k.SearchPageDown,
+			k.SearchOpen, k.SearchQueue, k.SearchDownload, k.SearchClose}},
+		{"Actions", []key.Binding{k.Download, k.DownloadRecursive, k.Queue, k.QueuePanel, k.Help, k.Quit, k.ForceQuit}},
+		{"Bookmarks", []key.Binding{k.Bookmark, k.Bookmarks}},
+		{"Marking", []key.Binding{k.Mark, k.MarkAll, k.InvertMarks, k.ClearMarks, k.DownloadMarked}},
+		{"Options", []key.Binding{k.PreScan, k.Extract, k.Folder, k.DeleteZip, k.FilterMode, k.MissingOnly, k.Details}},
+		{"Downloads", []key.Binding{k.Progress, k.Pause, k.Resume, k.Cancel}},
+		{"Queue and bookmark panels", []key.Binding{k.Close, k.MoveItemUp, k.MoveItemDown, k.Remove, k.ClearFinished, k.Rename}},
+	}
+}
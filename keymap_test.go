@@ -0,0 +1,210 @@
+package myrient_browser
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestNewKeyMap(t *testing.T) {
+	tests := []struct {
+		name      string
+		preset    string
+		overrides map[string][]string
+		binding   func(k keyMap) key.Binding
+		want      []string
+	}{
+		{"default", "", nil, func(k keyMap) key.Binding { return k.Home }, []string{"home"}},
+		{"vim sequence", "vim", nil, func(k keyMap) key.Binding { return k.Home }, []string{"g g", "home"}},
+		{"vim goto", "vim", nil, func(k keyMap) key.Binding { return k.GoTo }, []string{":"}},
+		{"untouched by preset", "vim", nil, func(k keyMap) key.Binding { return k.Sort }, []string{"o"}},
+		{"space", "", nil, func(k keyMap) key.Binding { return k.Mark }, []string{" "}},
+		{"override", "vim", map[string][]string{"up": {"ctrl+p"}}, func(k keyMap) key.Binding { return k.Up }, []string{"ctrl+p"}},
+		{"override space", "", map[string][]string{"mark": {"space", "M"}, "missing_only": {"N"}},
+			func(k keyMap) key.Binding { return k.Mark }, []string{" ", "M"}},
+		{"freed key", "", map[string][]string{"download": {"ctrl+d"}, "sort": {"d"}},
+			func(k keyMap) key.Binding { return k.Sort }, []string{"d"}},
+		{"panel key reused", "", map[string][]string{"close": {"d", "esc"}},
+			func(k keyMap) key.Binding { return k.Close }, []string{"d", "esc"}},
+		{"panel keys reused by another panel", "", map[string][]string{"rename": {"C"}},
+			func(k keyMap) key.Binding { return k.Rename }, []string{"C"}},
+		{"sequence override", "", map[string][]string{"goto": {"ctrl+g"}, "end": {"g e"}},
+			func(k keyMap) key.Binding { return k.End }, []string{"g e"}},
+		{"search", "vim", nil, func(k keyMap) key.Binding { return k.SearchDown }, []string{"down"}},
+		{"search override", "", map[string][]string{"search_close": {"ctrl+g", "esc"}},
+			func(k keyMap) key.Binding { return k.SearchClose }, []string{"ctrl+g", "esc"}},
+		{"search reuses a listing key", "", map[string][]string{"search_queue": {"+"}},
+			func(k keyMap) key.Binding { return k.SearchQueue }, []string{"+"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := newKeyMap(tt.preset, tt.overrides)
+			if err != nil {
+				t.Fatalf("newKeyMap failed: %v", err)
+			}
+			b := tt.binding(k)
+			if !slices.Equal(b.Keys(), tt.want) {
+				t.Errorf("keys = %q, want %q", b.Keys(), tt.want)
+			}
+			if !b.Enabled() {
+				t.Error("binding is disabled")
+			}
+		})
+	}
+}
+
+func TestNewKeyMapErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		preset    string
+		overrides map[string][]string
+		want      string
+	}{
+		{"unknown preset", "emacs", nil, `unknown keymap "emacs" (want default or vim)`},
+		{"unknown action", "", map[string][]string{"jump": {"j"}}, `unknown key action "jump"`},
+		{"conflict", "", map[string][]string{"sort": {"d"}}, `key "d" is bound to both sort and download`},
+		{"conflict with preset", "vim", map[string][]string{"sort": {"j"}}, `key "j" is bound to both down and sort`},
+		{"conflict on space", "", map[string][]string{"details": {"space"}}, `key "Space" is bound to both mark and details`},
+		{"conflict with modifier", "", map[string][]string{"help": {"ctrl+c"}}, `key "Ctrl+C" is bound to both help and force_quit`},
+		{"sequence conflict", "", map[string][]string{"goto": {"ctrl+g"}, "home": {"g g"}, "end": {"g g"}},
+			`key "gg" is bound to both home and end`},
+		{"prefix of a sequence", "", map[string][]string{"home": {"g g"}},
+			`key "g" is bound to goto but also starts a key sequence`},
+		{"prefix in vim", "vim", map[string][]string{"goto": {"g"}},
+			`key "g" is bound to goto but also starts a key sequence`},
+		{"search conflict", "", map[string][]string{"search_open": {"tab"}},
+			`key "Tab" is bound to both search_open and search_queue`},
+		{"search conflict with force quit", "", map[string][]string{"search_close": {"ctrl+c"}},
+			`key "Ctrl+C" is bound to both search_close and force_quit`},
+		{"panel conflict with quit", "", map[string][]string{"close": {"q"}},
+			`key "q" is bound to both quit and close`},
+		{"bookmark panel conflict", "", map[string][]string{"rename": {"x"}},
+			`key "x" is bound to both remove and rename`},
+		{"queue panel conflict", "", map[string][]string{"clear_finished": {"K"}},
+			`key "K" is bound to both move_item_up and clear_finished`},
+		{"queue panel conflict with a listing key", "", map[string][]string{"remove": {"p"}},
+			`key "p" is bound to both pause and remove`},
+		{"progress conflict", "", map[string][]string{"cancel": {"home"}},
+			`key "Home" is bound to both home and cancel`},
+		{"progress conflict with force quit", "", map[string][]string{"cancel": {"ctrl+c"}},
+			`key "Ctrl+C" is bound to both force_quit and cancel`},
+		{"panel key starts a sequence", "", map[string][]string{"clear_finished": {"c"}, "move_item_up": {"c c"}},
+			`key "c" is bound to clear_finished but also starts a key sequence`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newKeyMap(tt.preset, tt.overrides)
+			if err == nil {
+				t.Fatalf("newKeyMap succeeded, want error %q", tt.want)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("newKeyMap error = %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewKeyMapUnbind(t *testing.T) {
+	k, err := newKeyMap("", map[string][]string{"quit": {}})
+	if err != nil {
+		t.Fatalf("newKeyMap failed: %v", err)
+	}
+	if k.Quit.Enabled() {
+		t.Error("quit is still enabled")
+	}
+	if key.Matches(keyPress("q"), k.Quit) {
+		t.Error(`"q" still matches quit`)
+	}
+	if got := hint("", k.Quit); got != "" {
+		t.Errorf("hint for an unbound action = %q, want none", got)
+	}
+	if got := keyHint(k.Quit); got != "[unbound]" {
+		t.Errorf("keyHint for an unbound action = %q, want %q", got, "[unbound]")
+	}
+}
+
+func TestKeyLabel(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"q", "q"},
+		{"Q", "Q"},
+		{"+", "+"},
+		{" ", "Space"},
+		{"tab", "Tab"},
+		{"ctrl+c", "Ctrl+C"},
+		{"alt+left", "Alt+←"},
+		{"shift+up", "Shift+↑"},
+		{"ctrl+shift+x", "Ctrl+Shift+X"},
+		{"g g", "gg"},
+	}
+	for _, tt := range tests {
+		if got := keyLabel(tt.key); got != tt.want {
+			t.Errorf("keyLabel(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestHint(t *testing.T) {
+	k, err := newKeyMap("vim", nil)
+	if err != nil {
+		t.Fatalf("newKeyMap failed: %v", err)
+	}
+
+	tests := []struct {
+		got, want string
+	}{
+		{hint("", k.Progress), "[v] Progress"},
+		{hint("Move", k.Up, k.Down), "[k/j] Move"},
+		{hint("Top", k.Home), "[gg] Top"},
+		{keyHint(k.ForceQuit), "[Ctrl+C]"},
+		{hints(hint("", k.Pause), "", hint("", k.Resume)), "[p] Pause [r] Resume"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("got %q, want %q", tt.got, tt.want)
+		}
+	}
+}
+
+func TestSearchKeys(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Keymap = "vim"
+	opts.Keys = map[string][]string{"search_close": {"ctrl+g"}, "search_download": {}}
+	m := InitialModel(opts)
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("S")})
+	if !m.searching {
+		t.Fatal("the search key didn't open search")
+	}
+	for _, k := range []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune("j")},
+		{Type: tea.KeyEsc},
+		{Type: tea.KeyCtrlD},
+	} {
+		m.Update(k)
+	}
+	if !m.searching {
+		t.Fatal("search was closed by a key that isn't bound to close it")
+	}
+	if got := m.searchInput.Value(); got != "j" {
+		t.Errorf("query = %q, want %q", got, "j")
+	}
+
+	footer := m.searchView()
+	if !strings.Contains(footer, "[↑/↓] Move [Enter] Open [Tab] Queue [Ctrl+G] Close") {
+		t.Errorf("the search footer doesn't show the active keys:\n%s", footer)
+	}
+	if !strings.Contains(m.helpView(), "Ctrl+G") {
+		t.Error("the help overlay doesn't list the search keys")
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlG})
+	if m.searching {
+		t.Error("the close key didn't close search")
+	}
+}
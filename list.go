@@ -0,0 +1,138 @@
+package myrient_browser
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Entry is a file or directory returned by List. Size is -1 when the listing
+// does not give one, and Modified is zero when it has no date.
+type Entry struct {
+	Name     string    `json:"name"`
+	Path     string    `json:"path"`
+	URL      string    `json:"url"`
+	Dir      bool      `json:"dir"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified,omitzero"`
+}
+
+// ListOptions controls a headless directory listing.
+type ListOptions struct {
+	Recursive bool
+	Filter    string
+}
+
+// List returns the entries of the remote directory at path, which may be a
+// decoded path, an escaped path or a full URL under opts.BaseURL. Entry paths
+// are decoded and relative to the base URL.
+func List(opts Options, path string, lo ListOptions) ([]Entry, error) {
+	filterText := strings.ToLower(lo.Filter)
+	var result []Entry
+
+	depth := 0
+	if lo.Recursive {
+		depth = -1
+	}
+
+	basePath, err := ResolvePath(opts.BaseURL, path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = walkDirectory(opts, basePath, depth, func(dir string, entry fileEntry) error {
+		if matchesFilter(entry.Name, filterText) {
+			result = append(result, Entry{
+				Name:     entry.Name,
+				Path:     decodePath(dir + entry.Path),
+				URL:      opts.BaseURL + dir + entry.Path,
+				Dir:      strings.HasSuffix(entry.Path, "/"),
+				Size:     entry.Size,
+				Modified: entry.ModTime,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// walkDirectory calls fn for every entry of the escaped directory path,
+// skipping the parent entry. It descends into each subdirectory after fn has
+// been called for it, up to depth levels deep; a negative depth has no limit.
+func walkDirectory(opts Options, path string, depth int, fn func(dir string, entry fileEntry) error) error {
+	entries, err := fetchDirectory(opts, path)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.Path == "../" {
+			continue
+		}
+
+		if err := fn(path, entry); err != nil {
+			return err
+		}
+
+		if depth != 0 && strings.HasSuffix(entry.Path, "/") {
+			if err := walkDirectory(opts, path+entry.Path, depth-1, fn); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ResolvePath converts a user-supplied location into an escaped directory
+// path relative to baseURL. It accepts full URLs under baseURL as well as
+// escaped or plain paths, and always returns "" or a path ending in "/".
+// ".." segments are resolved, and a path that climbs above baseURL or a
+// full URL on another host or outside it is an error.
+func ResolvePath(baseURL, location string) (string, error) {
+	location = strings.TrimSpace(location)
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL %q: %w", baseURL, err)
+	}
+	if u, err := url.Parse(location); err == nil && u.Scheme != "" && u.Host != "" {
+		if !strings.EqualFold(u.Scheme, base.Scheme) || !strings.EqualFold(u.Host, base.Host) ||
+			!strings.HasPrefix(u.EscapedPath()+"/", base.EscapedPath()) {
+			return "", fmt.Errorf("URL is not under %s", baseURL)
+		}
+		location = strings.TrimPrefix(u.EscapedPath(), strings.TrimSuffix(base.EscapedPath(), "/"))
+	} else {
+		location = strings.TrimPrefix(location, base.Path)
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(location, "/") {
+		if decoded, err := url.PathUnescape(segment); err == nil {
+			segment = decoded
+		}
+		switch segment {
+		case "", ".":
+			continue
+		case "..":
+			if len(segments) == 0 {
+				return "", fmt.Errorf("path is not under %s", baseURL)
+			}
+			segments = segments[:len(segments)-1]
+			continue
+		}
+		if strings.Contains(segment, "/") {
+			return "", fmt.Errorf("invalid path segment %q", segment)
+		}
+		segments = append(segments, url.PathEscape(segment))
+	}
+
+	if len(segments) == 0 {
+		return "", nil
+	}
+	return strings.Join(segments, "/") + "/", nil
+}
@@ -0,0 +1,168 @@
+package myrient_browser
+
+import (
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestResolvePath(t *testing.T) {
+	const base = "https://myrient.erista.me/files/"
+
+	tests := []struct {
+		location string
+		want     string
+	}{
+		{"", ""},
+		{"/", ""},
+		{base, ""},
+		{"https://myrient.erista.me/files", ""},
+		{base + "No-Intro/", "No-Intro/"},
+		{base + "No-Intro/Nintendo%20-%20Game%20Boy/", "No-Intro/Nintendo%20-%20Game%20Boy/"},
+		{"HTTPS://Myrient.Erista.Me/files/Redump", "Redump/"},
+		{"/files/No-Intro", "No-Intro/"},
+		{"No-Intro", "No-Intro/"},
+		{"  No-Intro/  ", "No-Intro/"},
+		{"No-Intro//./Nintendo - Game Boy", "No-Intro/Nintendo%20-%20Game%20Boy/"},
+		{"No-Intro/Nintendo%20-%20Game%20Boy", "No-Intro/Nintendo%20-%20Game%20Boy/"},
+		{"Redump/A+B (Europe)", "Redump/A+B%20%28Europe%29/"},
+		{"Redump/A%2BB", "Redump/A+B/"},
+		{"100% Orange", "100%25%20Orange/"},
+		{"No-Intro/../Redump", "Redump/"},
+		{"No-Intro/Nintendo/..", "No-Intro/"},
+		{"No-Intro/%2E%2E/Redump", "Redump/"},
+		{"No-Intro/..", ""},
+		{base + "No-Intro/../Redump/", "Redump/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			got, err := ResolvePath(base, tt.location)
+			if err != nil {
+				t.Fatalf("ResolvePath(%q) failed: %v", tt.location, err)
+			}
+			if got != tt.want {
+				t.Errorf("ResolvePath(%q) = %q, want %q", tt.location, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolvePathForeignURL(t *testing.T) {
+	const base = "https://myrient.erista.me/files/"
+
+	for _, location := range []string{
+		"https://example.com/files/No-Intro/",
+		"http://myrient.erista.me/files/No-Intro/",
+		"https://myrient.erista.me:8443/files/No-Intro/",
+		"https://myrient.erista.me/other/No-Intro/",
+		"https://myrient.erista.me/filesystem/",
+		"https://myrient.erista.me.example.com/files/",
+	} {
+		t.Run(location, func(t *testing.T) {
+			got, err := ResolvePath(base, location)
+			if err == nil {
+				t.Fatalf("ResolvePath(%q) = %q, want an error", location, got)
+			}
+			if want := "URL is not under " + base; err.Error() != want {
+				t.Errorf("ResolvePath(%q) error = %q, want %q", location, err, want)
+			}
+		})
+	}
+}
+
+func TestResolvePathOutsideRoot(t *testing.T) {
+	const base = "https://myrient.erista.me/files/"
+
+	for _, location := range []string{
+		"..",
+		"../",
+		"Foo/../..",
+		"Foo/../../etc",
+		"/files/../..",
+		"%2E%2E",
+		"Foo/..%2F..",
+		base + "../",
+		base + "No-Intro/../../",
+	} {
+		t.Run(location, func(t *testing.T) {
+			if got, err := ResolvePath(base, location); err == nil {
+				t.Errorf("ResolvePath(%q) = %q, want an error", location, got)
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	srv := httptest.NewServer(mirrorHandler(crawlPages))
+	defer srv.Close()
+	opts := DefaultOptions()
+	opts.BaseURL = srv.URL + "/files/"
+
+	tests := []struct {
+		name string
+		path string
+		lo   ListOptions
+		want []string
+	}{
+		{"root", "", ListOptions{}, []string{"Set/", "top.zip"}},
+		{"directory", "Set", ListOptions{}, []string{"Set/Deep/", "Set/a.zip", "Set/notes.txt"}},
+		{"recursive", "", ListOptions{Recursive: true}, []string{"Set/", "Set/Deep/", "Set/Deep/b+c.zip", "Set/a.zip", "Set/notes.txt", "top.zip"}},
+		{"filter", "", ListOptions{Recursive: true, Filter: "ZIP"}, []string{"Set/Deep/b+c.zip", "Set/a.zip", "top.zip"}},
+		{"full URL", opts.BaseURL + "Set/Deep/", ListOptions{}, []string{"Set/Deep/b+c.zip"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := List(opts, tt.path, tt.lo)
+			if err != nil {
+				t.Fatalf("List failed: %v", err)
+			}
+			var paths []string
+			for _, e := range entries {
+				paths = append(paths, e.Path)
+				if e.Dir != strings.HasSuffix(e.Path, "/") {
+					t.Errorf("%s: dir = %v", e.Path, e.Dir)
+				}
+				if want := opts.BaseURL + e.Path; e.URL != want {
+					t.Errorf("%s: url = %q, want %q", e.Path, e.URL, want)
+				}
+			}
+			if !slices.Equal(paths, tt.want) {
+				t.Errorf("paths = %q, want %q", paths, tt.want)
+			}
+		})
+	}
+
+	if _, err := List(opts, "Missing", ListOptions{}); err == nil {
+		t.Error("listing a missing directory succeeded")
+	}
+}
+
+func TestWalkDirectory(t *testing.T) {
+	srv := httptest.NewServer(mirrorHandler(crawlPages))
+	defer srv.Close()
+	opts := DefaultOptions()
+	opts.BaseURL = srv.URL + "/files/"
+
+	tests := []struct {
+		depth int
+		want  []string
+	}{
+		{0, []string{"Set/", "top.zip"}},
+		{1, []string{"Set/", "Set/Deep/", "Set/a.zip", "Set/notes.txt", "top.zip"}},
+		{-1, []string{"Set/", "Set/Deep/", "Set/Deep/b+c.zip", "Set/a.zip", "Set/notes.txt", "top.zip"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		err := walkDirectory(opts, "", tt.depth, func(dir string, entry fileEntry) error {
+			got = append(got, dir+entry.Path)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("walkDirectory(%d) failed: %v", tt.depth, err)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("walkDirectory(%d) visited %q, want %q", tt.depth, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package myrient_browser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// Lister parses one kind of directory index page into entries.
+type Lister interface {
+	// Name identifies the format, as used by the lister option.
+	Name() string
+	// Detect reports whether body looks like a page in this format.
+	Detect(contentType string, body []byte) bool
+	// Parse extracts the entries of the directory at page. root is true
+	// for the top-level directory, which gets no parent entry.
+	Parse(page *url.URL, root bool, body []byte) ([]fileEntry, error)
+}
+
+// listers are tried in order when detecting the format of a page; the last
+// one accepts any HTML page.
+var listers = []Lister{
+	nginxJSONLister{},
+	myrientLister{},
+	autoindexLister{},
+}
+
+// listerFor returns the lister called name, or the first one that detects
+// the page when name is empty or "auto".
+func listerFor(name, contentType string, body []byte) (Lister, error) {
+	for _, l := range listers {
+		if name == "" || name == "auto" {
+			if l.Detect(contentType, body) {
+				return l, nil
+			}
+		} else if l.Name() == name {
+			return l, nil
+		}
+	}
+	if name == "" || name == "auto" {
+		return nil, fmt.Errorf("unrecognised directory index format (%s)", contentType)
+	}
+	return nil, fmt.Errorf("unknown lister %q", name)
+}
+
+// myrientLister parses the fancy index table used by Myrient, with name,
+// size and date columns.
+type myrientLister struct{}
+
+func (myrientLister) Name() string { return "myrient" }
+
+func (myrientLister) Detect(contentType string, body []byte) bool {
+	return strings.Contains(contentType, "html") &&
+		(bytes.Contains(body, []byte(`id="list"`)) || bytes.Contains(body, []byte("File Name")))
+}
+
+func (myrientLister) Parse(page *url.URL, root bool, body []byte) ([]fileEntry, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []fileEntry
+	doc.Find("table tr").Each(func(_ int, row *goquery.Selection) {
+		fileName := strings.TrimSpace(row.Find("td:nth-child(1)").Text())
+		href, _ := row.Find("td:nth-child(1) a").Attr("href")
+
+		if fileName == "" || fileName == "File Name" ||
+			fileName == "./" || fileName == "Parent directory/" ||
+			(root && fileName == "../") {
+			return
+		}
+
+		if fileName == "../" {
+			entries = append(entries, fileEntry{Name: "..", Path: "../", Size: -1})
+			return
+		}
+
+		ref, err := url.Parse(href)
+		if err != nil {
+			return
+		}
+		target := page.ResolveReference(ref)
+		if target.Host != page.Host || target.RawQuery != "" {
+			return
+		}
+		rel, ok := childPath(page, target)
+		if !ok {
+			return
+		}
+
+		decodedName, err := url.PathUnescape(fileName)
+		if err != nil {
+			decodedName = fileName
+		}
+
+		size, rounded := parseListedSize(row.Find("td:nth-child(2)").Text())
+		entries = append(entries, fileEntry{
+			Name:    decodedName,
+			Path:    rel,
+			Size:    size,
+			ModTime: parseModTime(row.Find("td:nth-child(3)").Text()),
+			Rounded: rounded,
+		})
+	})
+
+	return entries, nil
+}
+
+// autoindexLister parses the plain HTML indexes generated by nginx
+// autoindex and Apache mod_autoindex, in either <pre> or table layout.
+type autoindexLister struct{}
+
+func (autoindexLister) Name() string { return "autoindex" }
+
+func (autoindexLister) Detect(contentType string, _ []byte) bool {
+	return strings.Contains(contentType, "html")
+}
+
+func (autoindexLister) Parse(page *url.URL, root bool, body []byte) ([]fileEntry, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []fileEntry
+	seen := map[string]bool{}
+	doc.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
+		href, _ := a.Attr("href")
+		if href == "" || strings.HasPrefix(href, "?") || strings.HasPrefix(href, "#") {
+			return
+		}
+
+		ref, err := url.Parse(href)
+		if err != nil {
+			return
+		}
+		target := page.ResolveReference(ref)
+		if target.Host != page.Host || target.RawQuery != "" {
+			return
+		}
+
+		if !root && strings.HasPrefix(page.Path, target.Path) && target.Path != page.Path {
+			if !seen["../"] {
+				seen["../"] = true
+				entries = append(entries, fileEntry{Name: "..", Path: "../", Size: -1})
+			}
+			return
+		}
+
+		rel, ok := childPath(page, target)
+		if !ok || seen[rel] {
+			return
+		}
+		seen[rel] = true
+
+		name, err := url.PathUnescape(rel)
+		if err != nil {
+			name = rel
+		}
+
+		date, size, rounded := autoindexColumns(a)
+		entries = append(entries, fileEntry{
+			Name:    name,
+			Path:    rel,
+			Size:    size,
+			ModTime: parseModTime(date),
+			Rounded: rounded,
+		})
+	})
+
+	return entries, nil
+}
+
+// autoindexColumns finds the date and size shown next to a link, either in
+// the other cells of its table row or in the text following it in a <pre>
+// block. rounded is true if the size is only approximate.
+func autoindexColumns(a *goquery.Selection) (date string, size int64, rounded bool) {
+	if row := a.Closest("tr"); row.Length() > 0 {
+		size = -1
+		foundDate := false
+		row.Find("td").Each(func(_ int, td *goquery.Selection) {
+			if td.Find("a").Length() > 0 {
+				return
+			}
+			text := strings.TrimSpace(td.Text())
+			if !foundDate && !parseModTime(text).IsZero() {
+				date, foundDate = text, true
+				return
+			}
+			if foundDate && size < 0 {
+				size, rounded = parseListedSize(text)
+			}
+		})
+		return date, size, rounded
+	}
+
+	next := a.Nodes[0].NextSibling
+	if next == nil || next.Type != html.TextNode {
+		return "", -1, false
+	}
+	line, _, _ := strings.Cut(next.Data, "\n")
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return "", -1, false
+	}
+	size, rounded = parseListedSize(strings.Join(fields[2:], " "))
+	return fields[0] + " " + fields[1], size, rounded
+}
+
+// childPath returns the escaped path of target relative to the directory
+// page. Only direct children of the page are entries, so ok is false for
+// anything else.
+func childPath(page, target *url.URL) (rel string, ok bool) {
+	rel, ok = strings.CutPrefix(target.EscapedPath(), page.EscapedPath())
+	if !ok || rel == "" || strings.Contains(strings.TrimSuffix(rel, "/"), "/") {
+		return "", false
+	}
+	return rel, true
+}
+
+// nginxJSONLister parses the output of nginx "autoindex_format json".
+type nginxJSONLister struct{}
+
+func (nginxJSONLister) Name() string { return "json" }
+
+func (nginxJSONLister) Detect(contentType string, body []byte) bool {
+	return strings.Contains(contentType, "json") || bytes.HasPrefix(bytes.TrimSpace(body), []byte("["))
+}
+
+func (nginxJSONLister) Parse(_ *url.URL, root bool, body []byte) ([]fileEntry, error) {
+	var items []struct {
+		Name  string `json:"name"`
+		Type  string `json:"type"`
+		MTime string `json:"mtime"`
+		Size  *int64 `json:"size"`
+	}
+	if err := json.Unmarshal(body, &items); err != nil {
+		return nil, fmt.Errorf("invalid JSON index: %w", err)
+	}
+
+	// The JSON index has no parent link, so add one as the HTML indexes do.
+	entries := make([]fileEntry, 0, len(items)+1)
+	if !root {
+		entries = append(entries, fileEntry{Name: "..", Path: "../", Size: -1})
+	}
+	for _, item := range items {
+		entry := fileEntry{
+			Name:    item.Name,
+			Path:    url.PathEscape(item.Name),
+			Size:    -1,
+			ModTime: parseModTime(item.MTime),
+		}
+		if item.Type == "directory" {
+			entry.Name += "/"
+			entry.Path += "/"
+		} else if item.Size != nil {
+			entry.Size = *item.Size
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,301 @@
+package myrient_browser
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const myrientPage = `<!DOCTYPE html>
+<html><head><title>Myrient</title></head><body>
+<table id="list">
+<thead><tr><th>File Name</th><th>File Size</th><th>Date</th></tr></thead>
+<tbody>
+<tr><td class="link"><a href="../">../</a></td><td class="size">-</td><td class="date">-</td></tr>
+<tr><td class="link"><a href="./">./</a></td><td class="size">-</td><td class="date">-</td></tr>
+<tr><td class="link"><a href="Sub%20Dir/">Sub Dir/</a></td><td class="size">-</td><td class="date">15-Mar-2024 12:30</td></tr>
+<tr><td class="link"><a href="Game%20%28USA%29.zip">Game (USA).zip</a></td><td class="size">1.5 MiB</td><td class="date">15-Mar-2024 12:30</td></tr>
+<tr><td class="link"><a href="A+B%20%28Europe%29.zip">A+B (Europe).zip</a></td><td class="size">1024</td><td class="date">-</td></tr>
+<tr><td class="link"><a href="100%25%20Orange.zip">100% Orange.zip</a></td><td class="size">12 KiB</td><td class="date">2024-03-15 12:30:45</td></tr>
+</tbody></table>
+</body></html>`
+
+const nginxPage = `<html>
+<head><title>Index of /files/</title></head>
+<body>
+<h1>Index of /files/</h1><hr><pre><a href="../">../</a>
+<a href="Sub%20Dir/">Sub Dir/</a>                                           15-Mar-2024 12:30                   -
+<a href="Game%20(USA).zip">Game (USA).zip</a>                                     15-Mar-2024 12:30             1572864
+<a href="A+B%20(Europe).zip">A+B (Europe).zip</a>                                   15-Mar-2024 12:30                1024
+<a href="C%2BD.zip">C+D.zip</a>                                            15-Mar-2024 12:30                  12
+</pre><hr></body>
+</html>`
+
+const apachePage = `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 3.2 Final//EN">
+<html><head><title>Index of /files</title></head><body>
+<h1>Index of /files</h1>
+<table>
+<tr><th><a href="?C=N;O=D">Name</a></th><th><a href="?C=M;O=A">Last modified</a></th><th><a href="?C=S;O=A">Size</a></th></tr>
+<tr><td><a href="/">Parent Directory</a></td><td>&nbsp;</td><td align="right">  - </td></tr>
+<tr><td><a href="Sub%20Dir/">Sub Dir/</a></td><td align="right">2024-03-15 12:30  </td><td align="right">  - </td></tr>
+<tr><td><a href="A+B%20(Europe).zip">A+B (Europe).zip</a></td><td align="right">2024-03-15 12:30  </td><td align="right">1.5M</td></tr>
+<tr><td><a href="Sub%20Dir/nested.zip">nested.zip</a></td><td align="right">2024-03-15 12:30  </td><td align="right">1K</td></tr>
+<tr><td><a href="https://example.com/files/other.zip">other.zip</a></td><td align="right">2024-03-15 12:30  </td><td align="right">1K</td></tr>
+</table>
+</body></html>`
+
+const jsonPage = `[
+{ "name":"Sub Dir", "type":"directory", "mtime":"Fri, 15 Mar 2024 12:30:45 GMT" },
+{ "name":"Game (USA).zip", "type":"file", "mtime":"Fri, 15 Mar 2024 12:30:45 GMT", "size":1572864 },
+{ "name":"A+B (Europe).zip", "type":"file", "mtime":"Fri, 15 Mar 2024 12:30:45 GMT", "size":1024 },
+{ "name":"50% #1?.zip", "type":"file", "mtime":"bogus" }
+]`
+
+func TestListerParse(t *testing.T) {
+	minute := time.Date(2024, 3, 15, 12, 30, 0, 0, time.UTC)
+	second := time.Date(2024, 3, 15, 12, 30, 45, 0, time.UTC)
+	parent := fileEntry{Name: "..", Path: "../", Size: -1}
+
+	tests := []struct {
+		name   string
+		lister Lister
+		body   string
+		root   bool
+		want   []fileEntry
+	}{
+		{
+			name:   "myrient",
+			lister: myrientLister{},
+			body:   myrientPage,
+			want: []fileEntry{
+				parent,
+				{Name: "Sub Dir/", Path: "Sub%20Dir/", Size: -1, ModTime: minute},
+				{Name: "Game (USA).zip", Path: "Game%20%28USA%29.zip", Size: 3 << 19, ModTime: minute, Rounded: true},
+				{Name: "A+B (Europe).zip", Path: "A+B%20%28Europe%29.zip", Size: 1024},
+				{Name: "100% Orange.zip", Path: "100%25%20Orange.zip", Size: 12 << 10, ModTime: second, Rounded: true},
+			},
+		},
+		{
+			name:   "myrient root",
+			lister: myrientLister{},
+			body:   myrientPage,
+			root:   true,
+			want: []fileEntry{
+				{Name: "Sub Dir/", Path: "Sub%20Dir/", Size: -1, ModTime: minute},
+				{Name: "Game (USA).zip", Path: "Game%20%28USA%29.zip", Size: 3 << 19, ModTime: minute, Rounded: true},
+				{Name: "A+B (Europe).zip", Path: "A+B%20%28Europe%29.zip", Size: 1024},
+				{Name: "100% Orange.zip", Path: "100%25%20Orange.zip", Size: 12 << 10, ModTime: second, Rounded: true},
+			},
+		},
+		{
+			name:   "nginx autoindex",
+			lister: autoindexLister{},
+			body:   nginxPage,
+			want: []fileEntry{
+				parent,
+				{Name: "Sub Dir/", Path: "Sub%20Dir/", Size: -1, ModTime: minute},
+				{Name: "Game (USA).zip", Path: "Game%20(USA).zip", Size: 3 << 19, ModTime: minute},
+				{Name: "A+B (Europe).zip", Path: "A+B%20(Europe).zip", Size: 1024, ModTime: minute},
+				{Name: "C+D.zip", Path: "C%2BD.zip", Size: 12, ModTime: minute},
+			},
+		},
+		{
+			name:   "nginx autoindex root",
+			lister: autoindexLister{},
+			body:   nginxPage,
+			root:   true,
+			want: []fileEntry{
+				{Name: "Sub Dir/", Path: "Sub%20Dir/", Size: -1, ModTime: minute},
+				{Name: "Game (USA).zip", Path: "Game%20(USA).zip", Size: 3 << 19, ModTime: minute},
+				{Name: "A+B (Europe).zip", Path: "A+B%20(Europe).zip", Size: 1024, ModTime: minute},
+				{Name: "C+D.zip", Path: "C%2BD.zip", Size: 12, ModTime: minute},
+			},
+		},
+		{
+			name:   "apache autoindex",
+			lister: autoindexLister{},
+			body:   apachePage,
+			want: []fileEntry{
+				parent,
+				{Name: "Sub Dir/", Path: "Sub%20Dir/", Size: -1, ModTime: minute},
+				{Name: "A+B (Europe).zip", Path: "A+B%20(Europe).zip", Size: 3 << 19, ModTime: minute, Rounded: true},
+			},
+		},
+		{
+			name:   "json",
+			lister: nginxJSONLister{},
+			body:   jsonPage,
+			want: []fileEntry{
+				parent,
+				{Name: "Sub Dir/", Path: "Sub%20Dir/", Size: -1, ModTime: second},
+				{Name: "Game (USA).zip", Path: "Game%20%28USA%29.zip", Size: 3 << 19, ModTime: second},
+				{Name: "A+B (Europe).zip", Path: "A+B%20%28Europe%29.zip", Size: 1024, ModTime: second},
+				{Name: "50% #1?.zip", Path: "50%25%20%231%3F.zip", Size: -1},
+			},
+		},
+		{
+			name:   "json root",
+			lister: nginxJSONLister{},
+			body:   jsonPage,
+			root:   true,
+			want: []fileEntry{
+				{Name: "Sub Dir/", Path: "Sub%20Dir/", Size: -1, ModTime: second},
+				{Name: "Game (USA).zip", Path: "Game%20%28USA%29.zip", Size: 3 << 19, ModTime: second},
+				{Name: "A+B (Europe).zip", Path: "A+B%20%28Europe%29.zip", Size: 1024, ModTime: second},
+				{Name: "50% #1?.zip", Path: "50%25%20%231%3F.zip", Size: -1},
+			},
+		},
+	}
+
+	page, err := url.Parse("https://example.org/files/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.lister.Parse(page, tt.root, []byte(tt.body))
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if !slices.EqualFunc(got, tt.want, sameEntry) {
+				t.Errorf("Parse returned\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestListerPlusInName checks that a "+" in a file name survives the round
+// trip through the escaped path, which decoding it as a query would turn
+// into a space.
+func TestListerPlusInName(t *testing.T) {
+	tests := []struct {
+		name   string
+		lister Lister
+		body   string
+	}{
+		{"myrient", myrientLister{}, myrientPage},
+		{"autoindex", autoindexLister{}, nginxPage},
+		{"json", nginxJSONLister{}, jsonPage},
+	}
+
+	page, err := url.Parse("https://example.org/files/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := tt.lister.Parse(page, true, []byte(tt.body))
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			i := slices.IndexFunc(entries, func(e fileEntry) bool { return e.Name == "A+B (Europe).zip" })
+			if i < 0 {
+				t.Fatalf("no entry named %q in %+v", "A+B (Europe).zip", entries)
+			}
+			if got := decodePath(entries[i].Path); got != entries[i].Name {
+				t.Errorf("decodePath(%q) = %q, want %q", entries[i].Path, got, entries[i].Name)
+			}
+
+			opts := DefaultOptions()
+			opts.BaseURL = "https://example.org/files/"
+			opts.OutputDir = "/tmp/out"
+			info, err := newFileInfo("Sub%20Dir/", "/tmp/out/Sub Dir", entries[i], opts)
+			if err != nil {
+				t.Fatalf("newFileInfo failed: %v", err)
+			}
+			if info.filename != "A+B (Europe).zip" || info.path != "/tmp/out/Sub Dir/A+B (Europe).zip" {
+				t.Errorf("newFileInfo saves %q to %q, want %q in /tmp/out/Sub Dir", info.filename, info.path, "A+B (Europe).zip")
+			}
+		})
+	}
+}
+
+func TestJSONListerEscapesNames(t *testing.T) {
+	names := []string{"Game (USA).zip", "A+B.zip", "100% Orange", "#1?.zip", "Pokémon", "a;b=c&d", "a/b"}
+	var items []string
+	for _, name := range names {
+		items = append(items, fmt.Sprintf(`{"name":%q,"type":"file"}`, name))
+	}
+
+	entries, err := nginxJSONLister{}.Parse(nil, true, []byte("["+strings.Join(items, ",")+"]"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	for i, entry := range entries {
+		if decoded, err := url.PathUnescape(entry.Path); err != nil || decoded != names[i] || strings.Contains(entry.Path, "/") {
+			t.Errorf("%q has path %q, which decodes to %q (%v)", names[i], entry.Path, decoded, err)
+		}
+	}
+}
+
+func TestMyrientListerDirectChildren(t *testing.T) {
+	const page = `<table id="list">
+<tr><td><a href="../../x.zip">../../x.zip</a></td><td>1 KiB</td><td>-</td></tr>
+<tr><td><a href="/other/y.zip">y.zip</a></td><td>1 KiB</td><td>-</td></tr>
+<tr><td><a href="Sub/nested.zip">nested.zip</a></td><td>1 KiB</td><td>-</td></tr>
+<tr><td><a href="https://example.com/files/Set/z.zip">z.zip</a></td><td>1 KiB</td><td>-</td></tr>
+<tr><td><a href="a.zip?x=1">a.zip</a></td><td>1 KiB</td><td>-</td></tr>
+<tr><td><a href="ok.zip">ok.zip</a></td><td>1 KiB</td><td>-</td></tr>
+<tr><td><a href="Sub/">Sub/</a></td><td>-</td><td>-</td></tr>
+</table>`
+
+	pageURL, err := url.Parse("https://example.org/files/Set/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := myrientLister{}.Parse(pageURL, false, []byte(page))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	var paths []string
+	for _, e := range entries {
+		paths = append(paths, e.Path)
+	}
+	if want := []string{"ok.zip", "Sub/"}; !slices.Equal(paths, want) {
+		t.Errorf("paths = %q, want %q", paths, want)
+	}
+}
+
+func TestListerFor(t *testing.T) {
+	tests := []struct {
+		name        string
+		lister      string
+		contentType string
+		body        string
+		want        string
+		wantErr     bool
+	}{
+		{"myrient page", "auto", "text/html; charset=utf-8", myrientPage, "myrient", false},
+		{"nginx page", "auto", "text/html", nginxPage, "autoindex", false},
+		{"apache page", "", "text/html;charset=ISO-8859-1", apachePage, "autoindex", false},
+		{"json content type", "auto", "application/json", jsonPage, "json", false},
+		{"json body", "auto", "text/plain", jsonPage, "json", false},
+		{"unknown format", "auto", "text/plain", "hello", "", true},
+		{"forced lister", "myrient", "application/json", jsonPage, "myrient", false},
+		{"unknown lister", "ftp", "text/html", nginxPage, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := listerFor(tt.lister, tt.contentType, []byte(tt.body))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("listerFor returned %s, want an error", l.Name())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("listerFor failed: %v", err)
+			}
+			if l.Name() != tt.want {
+				t.Errorf("listerFor returned %s, want %s", l.Name(), tt.want)
+			}
+		})
+	}
+}
+
+func sameEntry(a, b fileEntry) bool {
+	return a.Name == b.Name && a.Path == b.Path && a.Size == b.Size && a.ModTime.Equal(b.ModTime) && a.Rounded == b.Rounded
+}
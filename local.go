@@ -0,0 +1,166 @@
+package myrient_browser
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// localState describes how a listed file compares with the local output
+// directory.
+type localState int
+
+const (
+	localMissing localState = iota
+	localPartial
+	localMismatch
+	localComplete
+)
+
+// localFile is the local state of a listed file. size is the size of the
+// local copy or .part file, and percent how much of a partial download is on
+// disk, when the listed size is known.
+type localFile struct {
+	state   localState
+	size    int64
+	percent float64
+}
+
+// sizesMatch reports whether a local file of size local matches the listed
+// size. Listings such as Myrient's only give sizes like "1.2 GiB", so when
+// the listed size is rounded, sizes within the rounding of one decimal place
+// are taken to match; exact sizes have to be equal.
+func sizesMatch(local, listed int64, rounded bool) bool {
+	if listed <= 0 || local == listed {
+		return true
+	}
+	if !rounded {
+		return false
+	}
+	diff := local - listed
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff*20 <= listed
+}
+
+// localStates compares the files among entries with their copies in
+// outputDir, keyed by entry path. Directories are left out.
+func localStates(outputDir string, entries []fileEntry) map[string]localFile {
+	sizes := map[string]int64{}
+	if dirEntries, err := os.ReadDir(outputDir); err == nil {
+		for _, d := range dirEntries {
+			if d.IsDir() {
+				continue
+			}
+			if info, err := d.Info(); err == nil {
+				sizes[d.Name()] = info.Size()
+			}
+		}
+	}
+
+	states := make(map[string]localFile, len(entries))
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Path, "/") {
+			continue
+		}
+		name := decodePath(entry.Path)
+
+		if size, ok := sizes[name]; ok {
+			if sizesMatch(size, entry.Size, entry.Rounded) {
+				states[entry.Path] = localFile{state: localComplete, size: size}
+			} else {
+				states[entry.Path] = localFile{state: localMismatch, size: size}
+			}
+			continue
+		}
+
+		if size, ok := sizes[name+".part"]; ok {
+			file := localFile{state: localPartial, size: size}
+			if entry.Size > 0 {
+				file.percent = min(float64(size)/float64(entry.Size)*100, 99.9)
+			}
+			states[entry.Path] = file
+			continue
+		}
+
+		states[entry.Path] = localFile{state: localMissing}
+	}
+	return states
+}
+
+// checkLocal compares the current listing with its local output directory.
+func (m *Model) checkLocal() {
+	m.local = localStates(outputDirFor(m.currentPath, m.optionsFor(m.currentPath)), m.entries)
+}
+
+// refreshLocal checks the listing again after files were downloaded. When
+// only missing files are shown the newly completed ones are hidden, keeping
+// the cursor on the same entry, or at the same row if it was hidden, and the
+// listing scrolled where it was.
+func (m *Model) refreshLocal() {
+	m.checkLocal()
+	if !m.missingOnly {
+		return
+	}
+
+	var selected string
+	if m.cursor < len(m.filtered) {
+		selected = m.entries[m.filtered[m.cursor]].Path
+	}
+	cursor, offset := m.cursor, m.viewport.offset
+
+	m.updateFilter()
+	m.cursor = max(min(cursor, len(m.filtered)-1), 0)
+	for i, idx := range m.filtered {
+		if m.entries[idx].Path == selected {
+			m.cursor = i
+			break
+		}
+	}
+	m.scrollTo(offset)
+}
+
+// refreshLocalProgress rechecks the listing whenever another download job
+// has finished.
+func (m *Model) refreshLocalProgress() {
+	if completed := atomic.LoadInt32(&m.downloadStats.completed); completed != m.localChecked {
+		m.localChecked = completed
+		m.refreshLocal()
+	}
+}
+
+// toggleMissingOnly hides or shows the files that are already complete
+// locally.
+func (m *Model) toggleMissingOnly() {
+	m.missingOnly = !m.missingOnly
+	m.replaceEntries(m.entries)
+	if m.missingOnly {
+		m.status = "Showing only files that aren't downloaded yet"
+	} else {
+		m.status = "Showing all files"
+	}
+}
+
+// missingOnlyHides reports whether the missing-only filter hides entry.
+func (m *Model) missingOnlyHides(entry fileEntry) bool {
+	return m.missingOnly && m.local[entry.Path].state == localComplete
+}
+
+// label returns the text shown for a file's local state in the listing.
+func (f localFile) label() string {
+	switch f.state {
+	case localComplete:
+		return "✓"
+	case localPartial:
+		if f.percent > 0 {
+			return fmt.Sprintf("%.0f%%", f.percent)
+		}
+		return "part"
+	case localMismatch:
+		return "≠ size"
+	default:
+		return "·"
+	}
+}
@@ -0,0 +1,118 @@
+package myrient_browser
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSizesMatch(t *testing.T) {
+	tests := []struct {
+		local, listed int64
+		rounded       bool
+		want          bool
+	}{
+		{100, 100, false, true},
+		{100, -1, false, true},
+		{0, 0, false, true},
+		{1288490188, 5 << 28, true, true},
+		{96, 100, true, true},
+		{94, 100, true, false},
+		{200, 100, true, false},
+		{1288490188, 5 << 28, false, false},
+		{96, 100, false, false},
+		{101, 100, false, false},
+	}
+	for _, tt := range tests {
+		if got := sizesMatch(tt.local, tt.listed, tt.rounded); got != tt.want {
+			t.Errorf("sizesMatch(%d, %d, %v) = %v, want %v", tt.local, tt.listed, tt.rounded, got, tt.want)
+		}
+	}
+}
+
+func TestLocalStates(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"done.zip":           "aaaa",
+		"short.zip":          "a",
+		"Game (USA).zip":     "aaaa",
+		"half.zip.part":      "aa",
+		"unknown.zip.part":   "a",
+		"Dir/inner.zip":      "aaaa",
+		"nested/missing.zip": "aaaa",
+	})
+
+	entries := []fileEntry{
+		{Name: "..", Path: "../", Size: -1},
+		{Name: "Dir/", Path: "Dir/", Size: -1},
+		{Name: "done.zip", Path: "done.zip", Size: 4},
+		{Name: "short.zip", Path: "short.zip", Size: 4},
+		{Name: "Game (USA).zip", Path: "Game%20%28USA%29.zip", Size: 4},
+		{Name: "half.zip", Path: "half.zip", Size: 4},
+		{Name: "unknown.zip", Path: "unknown.zip", Size: -1},
+		{Name: "missing.zip", Path: "missing.zip", Size: 4},
+	}
+	want := map[string]localFile{
+		"done.zip":             {state: localComplete, size: 4},
+		"short.zip":            {state: localMismatch, size: 1},
+		"Game%20%28USA%29.zip": {state: localComplete, size: 4},
+		"half.zip":             {state: localPartial, size: 2, percent: 50},
+		"unknown.zip":          {state: localPartial, size: 1},
+		"missing.zip":          {state: localMissing},
+	}
+
+	got := localStates(dir, entries)
+	if len(got) != len(want) {
+		t.Errorf("localStates returned %d states, want %d: %+v", len(got), len(want), got)
+	}
+	for path, w := range want {
+		if got[path] != w {
+			t.Errorf("state of %s = %+v, want %+v", path, got[path], w)
+		}
+	}
+
+	for path, state := range localStates(filepath.Join(dir, "absent"), entries) {
+		if state.state != localMissing {
+			t.Errorf("state of %s in a missing directory = %+v, want missing", path, state)
+		}
+	}
+}
+
+func TestRefreshLocalKeepsPosition(t *testing.T) {
+	tests := []struct {
+		name        string
+		missingOnly bool
+		done        string
+		cursor      string
+		row, offset int
+	}{
+		{"all files", false, "file 10.zip", "file 50.zip", 50, 45},
+		{"missing only", true, "file 10.zip", "file 50.zip", 49, 45},
+		{"missing only with the cursor's file done", true, "file 50.zip", "file 51.zip", 50, 45},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel(t, longPages)
+			m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+			if tt.missingOnly {
+				m.toggleMissingOnly()
+			}
+			m.cursor, m.viewport.offset = 50, 45
+
+			writeFiles(t, m.opts.OutputDir, map[string]string{tt.done: strings.Repeat("a", 1024)})
+			m.refreshLocal()
+
+			if m.local["file%20"+strings.TrimPrefix(tt.done, "file ")].state != localComplete {
+				t.Errorf("%s isn't complete after the refresh", tt.done)
+			}
+			if got := m.entries[m.filtered[m.cursor]].Name; got != tt.cursor || m.cursor != tt.row {
+				t.Errorf("cursor on %q at row %d, want %q at row %d", got, m.cursor, tt.cursor, tt.row)
+			}
+			if m.viewport.offset != tt.offset {
+				t.Errorf("offset = %d, want %d", m.viewport.offset, tt.offset)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package myrient_browser
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// markable reports whether entry can be marked; the parent entry can't.
+func markable(entry fileEntry) bool {
+	return entry.Path != "../"
+}
+
+// toggleMark marks or unmarks the entry under the cursor and moves down.
+func (m *Model) toggleMark() {
+	if m.cursor >= len(m.filtered) {
+		return
+	}
+	entry := m.entries[m.filtered[m.cursor]]
+	if markable(entry) {
+		if m.marked[entry.Path] {
+			delete(m.marked, entry.Path)
+		} else {
+			m.marked[entry.Path] = true
+		}
+	}
+	m.setCursor(m.cursor + 1)
+}
+
+// markAll marks every entry in the filtered view.
+func (m *Model) markAll() {
+	for _, idx := range m.filtered {
+		if entry := m.entries[idx]; markable(entry) {
+			m.marked[entry.Path] = true
+		}
+	}
+}
+
+// invertMarks flips the mark of every entry in the filtered view.
+func (m *Model) invertMarks() {
+	for _, idx := range m.filtered {
+		entry := m.entries[idx]
+		if !markable(entry) {
+			continue
+		}
+		if m.marked[entry.Path] {
+			delete(m.marked, entry.Path)
+		} else {
+			m.marked[entry.Path] = true
+		}
+	}
+}
+
+// clearMarks unmarks everything in the current directory.
+func (m *Model) clearMarks() {
+	m.marked = map[string]bool{}
+}
+
+// markedEntries returns the marked files and directories in listing order.
+func (m *Model) markedEntries() (files, dirs []fileEntry) {
+	for _, entry := range m.entries {
+		if !m.marked[entry.Path] {
+			continue
+		}
+		if strings.HasSuffix(entry.Path, "/") {
+			dirs = append(dirs, entry)
+		} else {
+			files = append(files, entry)
+		}
+	}
+	return files, dirs
+}
+
+// markedSummary describes the marked entries and the known size of the
+// marked files for the status line.
+func (m *Model) markedSummary() string {
+	files, dirs := m.markedEntries()
+	var size int64
+	for _, f := range files {
+		if f.Size > 0 {
+			size += f.Size
+		}
+	}
+
+	summary := fmt.Sprintf("%d marked (%s", len(files)+len(dirs), formatSize(size))
+	if len(dirs) > 0 {
+		summary += fmt.Sprintf(" + %d directories", len(dirs))
+	}
+	return summary + ")"
+}
+
+// downloadMarked downloads the marked entries, descending into marked
+// directories, and clears the marks. The marks are kept while another
+// download is running.
+func (m *Model) downloadMarked() tea.Cmd {
+	files, dirs := m.markedEntries()
+	if len(files) == 0 && len(dirs) == 0 {
+		m.status = "No entries marked - press " + keyHint(m.keys.Mark) + " to mark"
+		return nil
+	}
+	if m.downloadBusy() {
+		return nil
+	}
+	m.clearMarks()
+
+	if len(dirs) > 0 {
+		return m.startRecursiveDownload(files, dirs)
+	}
+	return m.startDownload(m.currentPath, files, fmt.Sprintf("%d marked files", len(files)))
+}
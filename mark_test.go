@@ -0,0 +1,82 @@
+package myrient_browser
+
+import "testing"
+
+func TestMarks(t *testing.T) {
+	entries := []fileEntry{
+		{Name: "..", Path: "../", Size: -1},
+		{Name: "Dir/", Path: "Dir/", Size: -1},
+		{Name: "a.zip", Path: "a.zip", Size: 1024},
+		{Name: "b.zip", Path: "b.zip", Size: 2048},
+		{Name: "c.txt", Path: "c.txt", Size: -1},
+	}
+
+	tests := []struct {
+		name  string
+		steps func(m *Model)
+		want  string
+	}{
+		{"none", func(m *Model) {}, "0 marked (0 B)"},
+		{"all", func(m *Model) { m.markAll() }, "4 marked (3.0 KiB + 1 directories)"},
+		{"inverted", func(m *Model) { m.markAll(); m.invertMarks() }, "0 marked (0 B)"},
+		{"parent", func(m *Model) { m.setCursor(0); m.toggleMark() }, "0 marked (0 B)"},
+		{"toggled", func(m *Model) {
+			m.setCursor(0)
+			m.toggleMark()
+			m.toggleMark()
+			m.toggleMark()
+		}, "2 marked (1.0 KiB + 1 directories)"},
+		{"toggled twice", func(m *Model) {
+			m.setCursor(2)
+			m.toggleMark()
+			m.setCursor(2)
+			m.toggleMark()
+		}, "0 marked (0 B)"},
+		{"invert some", func(m *Model) {
+			m.setCursor(2)
+			m.toggleMark()
+			m.invertMarks()
+		}, "3 marked (2.0 KiB + 1 directories)"},
+		{"all in the filtered view", func(m *Model) {
+			m.substringFilter = true
+			m.filterInput.SetValue("zip")
+			m.updateFilter()
+			m.markAll()
+		}, "2 marked (3.0 KiB)"},
+		{"cleared", func(m *Model) { m.markAll(); m.clearMarks() }, "0 marked (0 B)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := InitialModel(DefaultOptions())
+			m.replaceEntries(append([]fileEntry(nil), entries...))
+			tt.steps(m)
+			if got := m.markedSummary(); got != tt.want {
+				t.Errorf("markedSummary() = %q, want %q", got, tt.want)
+			}
+			if m.marked["../"] {
+				t.Error("the parent entry is marked")
+			}
+		})
+	}
+}
+
+func TestDownloadMarkedWhileBusy(t *testing.T) {
+	m := InitialModel(DefaultOptions())
+	m.replaceEntries([]fileEntry{{Name: "a.zip", Path: "a.zip", Size: 1024}})
+	m.markAll()
+	m.beginDownload(m.downloadOptions(), &downloadStats{total: 1})
+	running := m.downloadStats
+
+	if cmd := m.downloadMarked(); cmd != nil {
+		t.Error("a second download was started")
+	}
+	if m.downloadStats != running {
+		t.Error("the running download was replaced")
+	}
+	if !m.marked["a.zip"] {
+		t.Error("the marks were cleared although nothing was downloaded")
+	}
+	if want := "A download is already running - press [+] to queue more"; m.status != want {
+		t.Errorf("status = %q, want %q", m.status, want)
+	}
+}
@@ -3,7 +3,9 @@ package myrient_browser
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 	"time"
 
@@ -13,34 +15,97 @@ import (
 	"github.com/gocolly/colly"
 )
 
-func InitialModel() *Model {
+// InitialModel creates the browser model. Toggle defaults are taken from opts.
+func InitialModel(opts Options) *Model {
 	ti := textinput.New()
 	ti.Placeholder = "Type to filter..."
 	ti.CharLimit = 156
 
+	gi := textinput.New()
+	gi.Placeholder = "Path, Myrient URL or @bookmark"
+	gi.CharLimit = 1024
+
+	si := textinput.New()
+	si.Placeholder = "Search all collections..."
+	si.CharLimit = 156
+
+	bi := textinput.New()
+	bi.Placeholder = "Bookmark name"
+	bi.CharLimit = 64
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	m := &Model{
+		opts:            opts,
 		entries:         []fileEntry{},
 		filtered:        []int{},
+		details:         map[string]*entryDetail{},
+		marked:          map[string]bool{},
 		currentPath:     "",
-		pathStack:       []string{},
 		filterInput:     ti,
 		filtering:       false,
+		gotoInput:       gi,
+		searchInput:     si,
+		bookmarkInput:   bi,
 		progress:        progress.New(progress.WithDefaultGradient()),
-		skipScan:        false,
-		autoExtract:     false,
-		extractToFolder: false,
-		deleteZip:       false,
+		skipScan:        opts.SkipScan,
+		autoExtract:     opts.AutoExtract,
+		extractToFolder: opts.ExtractToFolder,
+		deleteZip:       opts.DeleteZip,
 		ctx:             ctx,
 		cancel:          cancel,
+		cache:           newListingCache(opts.CacheDir, opts.CacheTTL),
+	}
+	keys, err := newKeyMap(opts.Keymap, opts.Keys)
+	if err != nil {
+		m.lastError = err.Error()
+		keys, _ = newKeyMap("", nil)
+	}
+	m.keys = keys
+	m.restoreState()
+
+	queue, err := loadQueue(opts.QueuePath)
+	if err != nil {
+		m.lastError = err.Error()
+	}
+	m.queue = queue
+
+	bookmarks, err := LoadBookmarks(opts.BookmarksPath)
+	if err != nil {
+		m.lastError = err.Error()
 	}
+	m.bookmarks = bookmarks
 
 	return m
 }
 
 func (m *Model) Init() tea.Cmd {
-	return loadDirectory("")
+	return tea.Batch(m.navigateTo(m.opts.StartPath), loadIndexCmd(m.opts.IndexPath, m.opts.BaseURL))
+}
+
+// navigateTo visits the directory at location. A location of the form
+// "@name" opens the bookmark called name.
+func (m *Model) navigateTo(location string) tea.Cmd {
+	location, err := ExpandBookmark(m.bookmarks, location)
+	if err != nil {
+		return m.openFailed(location, err)
+	}
+	path, err := ResolvePath(m.opts.BaseURL, location)
+	if err != nil {
+		return m.openFailed(location, err)
+	}
+	return m.visit(path)
+}
+
+// downloadOptions returns the configured options with the toggles replaced by
+// their current values in the UI.
+func (m *Model) downloadOptions() Options {
+	opts := m.opts
+	opts.SkipScan = m.skipScan
+	opts.AutoExtract = m.autoExtract
+	opts.ExtractToFolder = m.extractToFolder
+	opts.DeleteZip = m.deleteZip
+	return opts
 }
 
 func tickCmd() tea.Cmd {
@@ -49,61 +114,167 @@ func tickCmd() tea.Cmd {
 	})
 }
 
-func loadDirectory(path string) tea.Cmd {
+func loadDirectory(opts Options, path string, cache *listingCache, refresh bool) tea.Cmd {
 	return func() tea.Msg {
-		var entries []fileEntry
-		c := colly.NewCollector()
+		if !refresh {
+			if cached, err := cache.get(opts.BaseURL, path); err == nil && cached != nil {
+				return dirLoadedMsg{
+					path:    path,
+					entries: cached.Entries,
+					cached:  cached,
+					stale:   cache.stale(cached),
+				}
+			}
+		}
 
-		c.OnHTML("table tr", func(e *colly.HTMLElement) {
-			fileName := strings.TrimSpace(e.ChildText("td:nth-child(1)"))
-			href := e.ChildAttr("td:nth-child(1) a", "href")
+		l, _, err := fetchListing(opts, path, nil)
+		if err != nil {
+			return dirErrorMsg{path: path, err: err}
+		}
+		_ = cache.put(l)
+		return dirLoadedMsg{path: path, entries: l.Entries}
+	}
+}
 
-			if fileName == "" || fileName == "File Name" ||
-				fileName == "./" || fileName == "Parent directory/" ||
-				(path == "" && fileName == "../") {
-				return
-			}
+// fetchDirectory downloads and parses the listing of path.
+func fetchDirectory(opts Options, path string) ([]fileEntry, error) {
+	l, _, err := fetchListing(opts, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return l.Entries, nil
+}
 
-			if fileName == "../" {
-				entries = append(entries, fileEntry{Name: "..", Path: "../"})
-				return
-			}
+// fetchListing downloads the index page of path and parses it with the
+// configured or detected Lister. When prev is given its validators are sent
+// as a conditional request, and notModified is true if the server reports
+// that prev is still current.
+func fetchListing(opts Options, path string, prev *listing) (l *listing, notModified bool, err error) {
+	var status int
+	var resp *colly.Response
+	c := colly.NewCollector()
 
-			decodedName, err := url.QueryUnescape(fileName)
-			if err != nil {
-				decodedName = fileName
-			}
+	c.OnResponse(func(r *colly.Response) {
+		resp = r
+	})
+	c.OnError(func(r *colly.Response, err error) {
+		status = r.StatusCode
+	})
 
-			entries = append(entries, fileEntry{
-				Name: decodedName,
-				Path: href,
-			})
-		})
+	hdr := http.Header{}
+	if prev != nil {
+		if prev.ETag != "" {
+			hdr.Set("If-None-Match", prev.ETag)
+		}
+		if prev.LastModified != "" {
+			hdr.Set("If-Modified-Since", prev.LastModified)
+		}
+	}
 
-		if err := c.Visit(baseURL + path); err != nil {
-			return errMsg{err: fmt.Errorf("failed to load directory: %w", err)}
+	pageURL := opts.BaseURL + path
+	if err := c.Request("GET", pageURL, nil, nil, hdr); err != nil {
+		if prev != nil && status == http.StatusNotModified {
+			return prev, true, nil
 		}
+		return nil, false, fmt.Errorf("failed to load directory %s: %w", decodePath(path), err)
+	}
+	if resp == nil {
+		return nil, false, fmt.Errorf("failed to load directory %s: empty response", decodePath(path))
+	}
+
+	contentType := strings.ToLower(resp.Headers.Get("Content-Type"))
+	lister, err := listerFor(opts.Lister, contentType, resp.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load directory %s: %w", decodePath(path), err)
+	}
 
-		return dirLoadedMsg(entries)
+	entries, err := lister.Parse(resp.Request.URL, path == "", resp.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to parse directory %s: %w", decodePath(path), err)
 	}
+
+	return &listing{
+		BaseURL:      opts.BaseURL,
+		Path:         path,
+		Entries:      entries,
+		ETag:         resp.Headers.Get("ETag"),
+		LastModified: resp.Headers.Get("Last-Modified"),
+		Fetched:      time.Now(),
+	}, false, nil
 }
 
+// decodePath returns the human-readable form of an escaped remote path.
+func decodePath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	decoded, err := url.PathUnescape(path)
+	if err != nil {
+		return path
+	}
+	return decoded
+}
+
+// entryMatcher returns a predicate applying the current filter and the
+// profile of the current directory to entries found while crawling.
+func (m *Model) entryMatcher() func(fileEntry) bool {
+	query, err := parseQuery(m.filterInput.Value())
+	if err != nil {
+		query = &filterQuery{}
+	}
+	substring := m.substringFilter
+	_, profile := m.opts.profileFor(m.currentPath)
+
+	return func(entry fileEntry) bool {
+		if _, _, ok := query.match(entry, substring); !ok {
+			return false
+		}
+		return profile == nil || profile.allows(entry.Name)
+	}
+}
+
+// matchesFilter reports whether name matches the lowercased filter text.
+func matchesFilter(name, filterText string) bool {
+	return strings.Contains(strings.ToLower(name), filterText)
+}
+
+// updateFilter rebuilds filtered from the filter query. Fuzzy matches are
+// ordered by score, best first; substring matches keep the listing order. If
+// the query doesn't parse, the error is kept for display and the previous
+// results stay in place.
 func (m *Model) updateFilter() {
-	filterText := strings.ToLower(m.filterInput.Value())
+	query, err := parseQuery(m.filterInput.Value())
+	if err != nil {
+		m.filterErr = err.Error()
+		return
+	}
+	m.filterErr = ""
+
 	m.filtered = []int{}
+	m.matches = map[int][]int{}
 
-	if filterText == "" {
-		for i := range m.entries {
-			m.filtered = append(m.filtered, i)
+	scores := map[int]int{}
+	for i, entry := range m.entries {
+		if m.missingOnlyHides(entry) {
+			continue
 		}
-	} else {
-		for i, entry := range m.entries {
-			if strings.Contains(strings.ToLower(entry.Name), filterText) {
-				m.filtered = append(m.filtered, i)
-			}
+		score, positions, ok := query.match(entry, m.substringFilter)
+		if !ok {
+			continue
+		}
+		m.filtered = append(m.filtered, i)
+		scores[i] = score
+		if len(positions) > 0 {
+			m.matches[i] = positions
 		}
 	}
 
+	if query.text != "" && !m.substringFilter {
+		sort.SliceStable(m.filtered, func(a, b int) bool {
+			return scores[m.filtered[a]] > scores[m.filtered[b]]
+		})
+	}
+
 	if len(m.filtered) > 0 {
 		m.cursor = 0
 		m.viewport.offset = 0
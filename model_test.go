@@ -0,0 +1,51 @@
+package myrient_browser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNavigateTo(t *testing.T) {
+	tests := []struct {
+		location string
+		path     string
+		err      string
+	}{
+		{"B", "B/", ""},
+		{"/files/B/", "B/", ""},
+		{"@games", "B/", ""},
+		{"typo", "A/", "Not Found"},
+		{"https://example.com/files/B/", "A/", "URL is not under"},
+		{"@missing", "A/", `unknown bookmark "missing"`},
+		{"A/../B", "B/", ""},
+		{"A/../..", "A/", "path is not under"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			m := newTestModel(t, historyPages)
+			m.bookmarks = []Bookmark{{Name: "games", Path: "B/"}}
+			runCmd(m, m.visit("A/"))
+
+			runCmd(m, m.navigateTo(tt.location))
+			if m.currentPath != tt.path {
+				t.Errorf("current path = %q, want %q", m.currentPath, tt.path)
+			}
+			if tt.err == "" {
+				if m.lastError != "" {
+					t.Errorf("navigateTo failed: %s", m.lastError)
+				}
+				return
+			}
+			if !strings.Contains(m.lastError, tt.err) {
+				t.Errorf("error = %q, want it to mention %q", m.lastError, tt.err)
+			}
+
+			// The listing of the directory we stayed in must still lead to
+			// files that exist.
+			entry := m.entries[m.filtered[len(m.filtered)-1]]
+			if url := m.entryURL(entry); url != m.opts.BaseURL+"A/a.zip" {
+				t.Errorf("URL of %s = %q, want it under A/", entry.Name, url)
+			}
+		})
+	}
+}
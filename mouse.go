@@ -0,0 +1,162 @@
+package myrient_browser
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// wheelStep is how many rows a turn of the mouse wheel scrolls.
+	wheelStep = 3
+	// doubleClickTime is how soon a second click on the same row opens it.
+	doubleClickTime = 400 * time.Millisecond
+	// breadcrumbTitle leads the breadcrumb bar and breadcrumbSeparator
+	// comes between its directories.
+	breadcrumbTitle     = "Myrient Browser - "
+	breadcrumbSeparator = " › "
+)
+
+// crumb is a segment of the breadcrumb bar: a directory on the way to the
+// current one and the columns of the title it takes up.
+type crumb struct {
+	label      string
+	path       string
+	start, end int
+}
+
+// breadcrumbs lays out the root and every directory down to the current one
+// in the title. Directories nearest the root are folded into "…" when the
+// bar would be wider than the terminal leaves room for.
+func (m *Model) breadcrumbs() []crumb {
+	crumbs := []crumb{{label: "Root"}}
+	path := ""
+	for _, segment := range strings.SplitAfter(m.currentPath, "/") {
+		if segment == "" {
+			continue
+		}
+		path += segment
+		crumbs = append(crumbs, crumb{label: strings.TrimSuffix(decodePath(segment), "/"), path: path})
+	}
+
+	width := m.viewport.width
+	if width == 0 {
+		width = 80
+	}
+	room := width - lipgloss.Width(m.titleLabels())
+	barWidth := func() int {
+		w := lipgloss.Width(breadcrumbTitle) + lipgloss.Width(breadcrumbSeparator)*(len(crumbs)-1)
+		for _, c := range crumbs {
+			w += lipgloss.Width(c.label)
+		}
+		return w
+	}
+	if barWidth() > room && len(crumbs) > 2 {
+		// The folded crumb goes to the deepest of the directories it hides.
+		crumbs[1].label = "…"
+		for barWidth() > room && len(crumbs) > 3 {
+			crumbs[1].path = crumbs[2].path
+			crumbs = append(crumbs[:2], crumbs[3:]...)
+		}
+	}
+
+	x := lipgloss.Width(breadcrumbTitle)
+	for i := range crumbs {
+		crumbs[i].start = x
+		crumbs[i].end = x + lipgloss.Width(crumbs[i].label)
+		x = crumbs[i].end + lipgloss.Width(breadcrumbSeparator)
+	}
+	return crumbs
+}
+
+// breadcrumbBar renders the title with the path to the current directory,
+// its ancestors underlined to show they can be clicked.
+func (m *Model) breadcrumbBar() string {
+	titleStyle := lipgloss.NewStyle().Bold(true)
+	linkStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("12")).Underline(true)
+	separator := lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render(breadcrumbSeparator)
+
+	crumbs := m.breadcrumbs()
+	parts := make([]string, len(crumbs))
+	for i, c := range crumbs {
+		if i == len(crumbs)-1 {
+			parts[i] = titleStyle.Render(c.label)
+		} else {
+			parts[i] = linkStyle.Render(c.label)
+		}
+	}
+	return titleStyle.Render(breadcrumbTitle) + strings.Join(parts, separator)
+}
+
+// handleMouse scrolls the listing with the wheel, moves the cursor to a
+// clicked row and opens it on a double click, and goes to a directory
+// clicked in the breadcrumb bar. The mouse only works on the listing.
+func (m *Model) handleMouse(msg tea.MouseMsg) tea.Cmd {
+	if m.lastError != "" || (m.downloading && m.showProgress) || m.searching ||
+		m.showBookmarks || m.showQueue || m.showHelp || m.goingTo || m.naming {
+		return nil
+	}
+
+	switch {
+	case msg.Button == tea.MouseButtonWheelUp:
+		m.scroll(-wheelStep)
+	case msg.Button == tea.MouseButtonWheelDown:
+		m.scroll(wheelStep)
+	case msg.Button == tea.MouseButtonLeft && msg.Action == tea.MouseActionPress:
+		return m.click(msg.X, msg.Y)
+	}
+	return nil
+}
+
+// scroll moves the listing by delta rows, taking the cursor along when it
+// would leave the view.
+func (m *Model) scroll(delta int) {
+	if len(m.filtered) == 0 {
+		return
+	}
+	height := max(m.viewport.height, 1)
+	m.viewport.offset = max(min(m.viewport.offset+delta, len(m.filtered)-height), 0)
+	m.cursor = min(max(m.cursor, m.viewport.offset), m.viewport.offset+height-1, len(m.filtered)-1)
+}
+
+// click handles a left click at column x of screen row y. The view starts
+// with the breadcrumb bar on row 0 and the listing on the rows from
+// listTop, as laid out by layout.
+func (m *Model) click(x, y int) tea.Cmd {
+	if y == 0 {
+		for _, c := range m.breadcrumbs() {
+			if x < c.start || x >= c.end || c.path == m.currentPath {
+				continue
+			}
+			// Put the cursor on the directory we came up from.
+			rest := strings.TrimPrefix(m.currentPath, c.path)
+			m.jumpTo = rest[:strings.Index(rest, "/")+1]
+			return m.visit(c.path)
+		}
+		return nil
+	}
+
+	if list, _ := m.listWidth(); x >= list {
+		return nil
+	}
+	row := y - m.listTop()
+	if m.viewport.offset > 0 {
+		// Skip the "More items above" line.
+		row--
+	}
+	i := m.viewport.offset + row
+	if row < 0 || row >= m.viewport.height || i >= len(m.filtered) {
+		return nil
+	}
+
+	double := i == m.clickIndex && time.Since(m.clickTime) < doubleClickTime
+	m.setCursor(i)
+	if double {
+		m.clickTime = time.Time{}
+		return m.openEntry()
+	}
+	m.clickTime, m.clickIndex = time.Now(), i
+	return nil
+}
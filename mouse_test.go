@@ -0,0 +1,171 @@
+package myrient_browser
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestBreadcrumbs(t *testing.T) {
+	tests := []struct {
+		path   string
+		width  int
+		labels []string
+		paths  []string
+	}{
+		{"", 120, []string{"Root"}, []string{""}},
+		{"A/C/", 120, []string{"Root", "A", "C"}, []string{"", "A/", "A/C/"}},
+		{
+			"Alpha%20Collection/Beta%20Set/Gamma/Delta/", 120,
+			[]string{"Root", "Alpha Collection", "Beta Set", "Gamma", "Delta"},
+			[]string{"", "Alpha%20Collection/", "Alpha%20Collection/Beta%20Set/", "Alpha%20Collection/Beta%20Set/Gamma/", "Alpha%20Collection/Beta%20Set/Gamma/Delta/"},
+		},
+		{
+			"Alpha%20Collection/Beta%20Set/Gamma/Delta/", 60,
+			[]string{"Root", "…", "Gamma", "Delta"},
+			[]string{"", "Alpha%20Collection/Beta%20Set/", "Alpha%20Collection/Beta%20Set/Gamma/", "Alpha%20Collection/Beta%20Set/Gamma/Delta/"},
+		},
+		{
+			"Alpha%20Collection/Beta%20Set/Gamma/Delta/", 20,
+			[]string{"Root", "…", "Delta"},
+			[]string{"", "Alpha%20Collection/Beta%20Set/Gamma/", "Alpha%20Collection/Beta%20Set/Gamma/Delta/"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			m := InitialModel(DefaultOptions())
+			m.currentPath = tt.path
+			m.viewport.width = tt.width
+
+			crumbs := m.breadcrumbs()
+			var labels, paths []string
+			x := lipgloss.Width(breadcrumbTitle)
+			for _, c := range crumbs {
+				labels = append(labels, c.label)
+				paths = append(paths, c.path)
+				if c.start != x || c.end != x+lipgloss.Width(c.label) {
+					t.Errorf("%s spans %d-%d, want %d-%d", c.label, c.start, c.end, x, x+lipgloss.Width(c.label))
+				}
+				x = c.end + lipgloss.Width(breadcrumbSeparator)
+			}
+			if !slices.Equal(labels, tt.labels) {
+				t.Errorf("labels = %q, want %q", labels, tt.labels)
+			}
+			if !slices.Equal(paths, tt.paths) {
+				t.Errorf("paths = %q, want %q", paths, tt.paths)
+			}
+		})
+	}
+}
+
+// click presses the left mouse button at column x of screen row y.
+func click(m *Model, x, y int) {
+	_, cmd := m.Update(tea.MouseMsg{X: x, Y: y, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress})
+	runCmd(m, cmd)
+}
+
+func TestBreadcrumbClick(t *testing.T) {
+	tests := []struct {
+		name   string
+		crumb  int
+		column func(c crumb) int
+		path   string
+		cursor string
+	}{
+		{"root", 0, func(c crumb) int { return c.start }, "", "A/"},
+		{"parent", 1, func(c crumb) int { return c.end - 1 }, "A/", "C/"},
+		{"current directory", 2, func(c crumb) int { return c.start }, "A/C/", "../"},
+		{"separator", 1, func(c crumb) int { return c.end }, "A/C/", "../"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel(t, historyPages)
+			m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+			runCmd(m, m.visit("A/C/"))
+
+			click(m, tt.column(m.breadcrumbs()[tt.crumb]), 0)
+			if m.currentPath != tt.path {
+				t.Errorf("current path = %q, want %q", m.currentPath, tt.path)
+			}
+			if got := m.entries[m.filtered[m.cursor]].Path; got != tt.cursor {
+				t.Errorf("cursor on %q, want %q", got, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestRowClick(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset int
+		row    int
+		cursor int
+	}{
+		{"first row", 0, 0, 0},
+		{"third row", 0, 2, 2},
+		{"scrolled", 10, 1, 10},
+		{"more items above", 10, 0, 12},
+		{"below the listing", 0, 200, 12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel(t, longPages)
+			m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+			m.viewport.offset = tt.offset
+			m.cursor = 12
+
+			click(m, 2, m.listTop()+tt.row)
+			if m.cursor != tt.cursor {
+				t.Errorf("cursor = %d, want %d", m.cursor, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestRowDoubleClick(t *testing.T) {
+	m := newTestModel(t, historyPages)
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+
+	click(m, 2, m.listTop()+1)
+	if m.currentPath != "" {
+		t.Fatalf("a single click opened %q", m.currentPath)
+	}
+	click(m, 2, m.listTop()+1)
+	if m.currentPath != "B/" {
+		t.Errorf("current path = %q after a double click on B, want %q", m.currentPath, "B/")
+	}
+}
+
+func TestWheelScroll(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset int
+		cursor int
+		button tea.MouseButton
+		want   [2]int
+	}{
+		{"down takes the cursor along", 0, 0, tea.MouseButtonWheelDown, [2]int{3, 3}},
+		{"down leaves a visible cursor", 0, 5, tea.MouseButtonWheelDown, [2]int{3, 5}},
+		{"up leaves a visible cursor", 6, 8, tea.MouseButtonWheelUp, [2]int{3, 8}},
+		{"up stops at the top", 1, 1, tea.MouseButtonWheelUp, [2]int{0, 1}},
+		{"down stops at the end", 99, 99, tea.MouseButtonWheelDown, [2]int{-1, 99}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel(t, longPages)
+			m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+			m.viewport.offset, m.cursor = tt.offset, tt.cursor
+
+			m.Update(tea.MouseMsg{Button: tt.button, Action: tea.MouseActionPress})
+			want := tt.want
+			if want[0] < 0 {
+				want[0] = len(m.filtered) - m.viewport.height
+			}
+			if got := [2]int{m.viewport.offset, m.cursor}; got != want {
+				t.Errorf("offset, cursor = %v, want %v", got, want)
+			}
+		})
+	}
+}
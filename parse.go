@@ -0,0 +1,114 @@
+package myrient_browser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// sizeUnits maps the unit suffixes used by directory listings to their
+// multipliers. Single-letter and IEC units are binary, two-letter units are
+// decimal.
+var sizeUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kib": 1 << 10,
+	"kb":  1e3,
+	"m":   1 << 20,
+	"mib": 1 << 20,
+	"mb":  1e6,
+	"g":   1 << 30,
+	"gib": 1 << 30,
+	"gb":  1e9,
+	"t":   1 << 40,
+	"tib": 1 << 40,
+	"tb":  1e12,
+}
+
+// parseSize parses a listing size such as "1.2 GiB", "512K" or "1024". It
+// returns -1 for "-" and anything it does not understand.
+func parseSize(s string) int64 {
+	size, _ := parseListedSize(s)
+	return size
+}
+
+// parseListedSize parses a size as parseSize does and also reports whether
+// it was given in a unit larger than a byte, so that it is only as exact as
+// the rounding of the listing.
+func parseListedSize(s string) (size int64, rounded bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "-" {
+		return -1, false
+	}
+
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.' && r != ','
+	})
+	number, unit := s, ""
+	if i >= 0 {
+		number, unit = s[:i], strings.TrimSpace(s[i:])
+	}
+
+	value, err := strconv.ParseFloat(strings.ReplaceAll(number, ",", ""), 64)
+	if err != nil {
+		return -1, false
+	}
+	multiplier, ok := sizeUnits[strings.ToLower(unit)]
+	if !ok {
+		return -1, false
+	}
+	return int64(value * multiplier), multiplier > 1
+}
+
+// formatSize renders a byte count with binary units, or "-" when unknown.
+func formatSize(n int64) string {
+	if n < 0 {
+		return "-"
+	}
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	units := []string{"KiB", "MiB", "GiB", "TiB"}
+	value := float64(n) / 1024
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
+
+// dateLayouts are the modification date formats seen in directory listings.
+var dateLayouts = []string{
+	"02-Jan-2006 15:04",
+	"02-Jan-2006 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+	"2006-Jan-02 15:04",
+	time.RFC1123,
+	time.RFC3339,
+}
+
+// parseModTime parses a listing date, returning the zero time for "-" and
+// unrecognised formats.
+func parseModTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// formatModTime renders a modification date for the listing columns.
+func formatModTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2006-01-02 15:04")
+}
@@ -0,0 +1,117 @@
+package myrient_browser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		rounded bool
+	}{
+		{"1024", 1024, false},
+		{" 1024 ", 1024, false},
+		{"1,024", 1024, false},
+		{"0", 0, false},
+		{"12 B", 12, false},
+		{"512K", 512 << 10, true},
+		{"512 KiB", 512 << 10, true},
+		{"512 kB", 512000, true},
+		{"1.5M", 3 << 19, true},
+		{"1.25 GiB", 5 << 28, true},
+		{"2 GB", 2e9, true},
+		{"1 T", 1 << 40, true},
+		{"3 TB", 3e12, true},
+		{"", -1, false},
+		{"-", -1, false},
+		{"big", -1, false},
+		{"1.2.3 MiB", -1, false},
+		{"12 PB", -1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := parseSize(tt.in); got != tt.want {
+				t.Errorf("parseSize(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+			if got, rounded := parseListedSize(tt.in); got != tt.want || rounded != tt.rounded {
+				t.Errorf("parseListedSize(%q) = %d, %v, want %d, %v", tt.in, got, rounded, tt.want, tt.rounded)
+			}
+		})
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		in   int64
+		want string
+	}{
+		{-1, "-"},
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{3 << 19, "1.5 MiB"},
+		{5 << 40, "5.0 TiB"},
+		{2048 << 40, "2048.0 TiB"},
+	}
+	for _, tt := range tests {
+		if got := formatSize(tt.in); got != tt.want {
+			t.Errorf("formatSize(%d) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseModTime(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"15-Mar-2024 12:30", time.Date(2024, 3, 15, 12, 30, 0, 0, time.UTC)},
+		{"15-Mar-2024 12:30:45", time.Date(2024, 3, 15, 12, 30, 45, 0, time.UTC)},
+		{"2024-03-15 12:30", time.Date(2024, 3, 15, 12, 30, 0, 0, time.UTC)},
+		{" 2024-03-15 12:30:45 ", time.Date(2024, 3, 15, 12, 30, 45, 0, time.UTC)},
+		{"2024-Mar-15 12:30", time.Date(2024, 3, 15, 12, 30, 0, 0, time.UTC)},
+		{"Fri, 15 Mar 2024 12:30:45 UTC", time.Date(2024, 3, 15, 12, 30, 45, 0, time.UTC)},
+		{"2024-03-15T12:30:45Z", time.Date(2024, 3, 15, 12, 30, 45, 0, time.UTC)},
+		{"-", time.Time{}},
+		{"", time.Time{}},
+		{"yesterday", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := parseModTime(tt.in); !got.Equal(tt.want) {
+				t.Errorf("parseModTime(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateRange(t *testing.T) {
+	tests := []struct {
+		in         string
+		start, end time.Time
+	}{
+		{"2024", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"2024-02", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"2024-12", time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"2024-02-29", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			start, end, err := parseDateRange(tt.in)
+			if err != nil {
+				t.Fatalf("parseDateRange(%q) failed: %v", tt.in, err)
+			}
+			if !start.Equal(tt.start) || !end.Equal(tt.end) {
+				t.Errorf("parseDateRange(%q) = %v - %v, want %v - %v", tt.in, start, end, tt.start, tt.end)
+			}
+		})
+	}
+
+	for _, in := range []string{"", "24", "2024-13", "2023-02-29", "15-Mar-2024"} {
+		if _, _, err := parseDateRange(in); err == nil {
+			t.Errorf("parseDateRange(%q) succeeded, want error", in)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package myrient_browser
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Extract modes for a profile.
+const (
+	extractNone   = "none"
+	extractFlat   = "flat"
+	extractFolder = "folder"
+)
+
+// Profile overrides download settings for the remote directories it is
+// bound to. Unset fields keep the values of the current toggles.
+type Profile struct {
+	// Prefixes are decoded remote paths, such as "Redump/", that the
+	// profile applies to. They match whole directory names, so "Redump"
+	// covers "Redump/" but not "RedumpExtras/". The longest matching prefix
+	// wins.
+	Prefixes []string `toml:"prefixes"`
+	// OutputDir replaces the download root.
+	OutputDir string `toml:"output_dir"`
+	// Extract is one of "none", "flat" or "folder".
+	Extract string `toml:"extract"`
+	// DeleteZip controls whether archives are kept after extraction.
+	DeleteZip *bool `toml:"delete_zip"`
+	// Include and Exclude are globs matched against file names when
+	// downloading a whole view. A file must match an include pattern, if
+	// any are given, and no exclude pattern.
+	Include []string `toml:"include"`
+	Exclude []string `toml:"exclude"`
+}
+
+// validate checks the extract mode and glob patterns of the profile.
+func (p *Profile) validate() error {
+	switch p.Extract {
+	case "", extractNone, extractFlat, extractFolder:
+	default:
+		return fmt.Errorf("invalid extract mode %q", p.Extract)
+	}
+
+	for _, pattern := range append(append([]string{}, p.Include...), p.Exclude...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// apply returns opts with the profile's overrides.
+func (p *Profile) apply(opts Options) Options {
+	if p.OutputDir != "" {
+		opts.OutputDir = p.OutputDir
+	}
+
+	switch p.Extract {
+	case extractNone:
+		opts.AutoExtract = false
+	case extractFlat:
+		opts.AutoExtract = true
+		opts.ExtractToFolder = false
+	case extractFolder:
+		opts.AutoExtract = true
+		opts.ExtractToFolder = true
+	}
+
+	if p.DeleteZip != nil {
+		opts.DeleteZip = *p.DeleteZip
+	}
+	return opts
+}
+
+// extractMode returns the extract mode opts amount to.
+func extractMode(opts Options) string {
+	switch {
+	case !opts.AutoExtract:
+		return extractNone
+	case opts.ExtractToFolder:
+		return extractFolder
+	default:
+		return extractFlat
+	}
+}
+
+// allows reports whether a file named name passes the profile's filters.
+func (p *Profile) allows(name string) bool {
+	for _, pattern := range p.Exclude {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return false
+		}
+	}
+
+	if len(p.Include) == 0 {
+		return true
+	}
+	for _, pattern := range p.Include {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// profileFor returns the profile bound to the longest prefix of the escaped
+// remote path, or nil if none applies. Of two profiles with an equally long
+// prefix, the one whose name sorts first wins.
+func (o Options) profileFor(path string) (string, *Profile) {
+	decoded := strings.TrimPrefix(decodePath(path), "/")
+
+	var bestName string
+	var best *Profile
+	bestLen := -1
+	for name, p := range o.Profiles {
+		for _, prefix := range p.Prefixes {
+			prefix = strings.TrimPrefix(prefix, "/")
+			if prefix != "" && !strings.HasSuffix(prefix, "/") {
+				prefix += "/"
+			}
+			if !strings.HasPrefix(decoded, prefix) {
+				continue
+			}
+			if len(prefix) > bestLen || len(prefix) == bestLen && name < bestName {
+				bestName, best, bestLen = name, &p, len(prefix)
+			}
+		}
+	}
+	return bestName, best
+}
+
+// withProfile applies the profile for path to opts and filters files by it.
+func (o Options) withProfile(path string, files []fileEntry) (Options, []fileEntry) {
+	_, p := o.profileFor(path)
+	if p == nil {
+		return o, files
+	}
+
+	var allowed []fileEntry
+	for _, f := range files {
+		if p.allows(f.Name) {
+			allowed = append(allowed, f)
+		}
+	}
+	return p.apply(o), allowed
+}
+
+// optionsFor returns the download options for the remote directory path,
+// combining the current toggles with the matching profile.
+func (m *Model) optionsFor(path string) Options {
+	opts := m.downloadOptions()
+	if _, p := opts.profileFor(path); p != nil {
+		return p.apply(opts)
+	}
+	return opts
+}
@@ -0,0 +1,46 @@
+package myrient_browser
+
+import "testing"
+
+func TestProfileFor(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Profiles = map[string]Profile{
+		"redump":  {Prefixes: []string{"Redump/"}},
+		"psx":     {Prefixes: []string{"Redump/Sony - PlayStation/", "/Other/PSX/"}},
+		"zeta":    {Prefixes: []string{"No-Intro/"}},
+		"alpha":   {Prefixes: []string{"No-Intro/"}},
+		"tosec":   {Prefixes: []string{"TOSEC-ISO"}},
+		"nothing": {},
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"", ""},
+		{"TOSEC/", ""},
+		{"Redump/", "redump"},
+		{"Redump/Sega%20-%20Saturn/", "redump"},
+		{"Redump/Sony%20-%20PlayStation/", "psx"},
+		{"Redump/Sony%20-%20PlayStation%202/", "redump"},
+		{"Other/PSX/Games/", "psx"},
+		{"No-Intro/Nintendo%20-%20Game%20Boy/", "alpha"},
+		{"RedumpExtras/", ""},
+		{"No-Intro%20Extras/", ""},
+		{"Redump/Sony%20-%20PlayStation%20-%20BIOS/", "redump"},
+		{"TOSEC-ISO/", "tosec"},
+		{"TOSEC-ISO/Sega/", "tosec"},
+		{"TOSEC-ISO-Extras/", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			// Map order varies between runs, so a tie must not depend on it.
+			for range 20 {
+				name, p := opts.profileFor(tt.path)
+				if name != tt.want || (p == nil) != (tt.want == "") {
+					t.Fatalf("profileFor(%q) = %q, want %q", tt.path, name, tt.want)
+				}
+			}
+		})
+	}
+}
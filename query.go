@@ -0,0 +1,361 @@
+package myrient_browser
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// filterQuery is a parsed filter such as
+//
+//	mario ext:zip size>100MB region:USA -beta date>=2024-01 "kart ds" /\bv1\.1\b/
+//
+// Plain words are matched against the name fuzzily or as a substring,
+// depending on the filter mode; every other term must hold as well. A leading
+// "-" negates any term.
+type filterQuery struct {
+	text    string
+	exclude []string
+	terms   []queryTerm
+}
+
+// queryTerm is a predicate on an entry. positions, when it matches, are the
+// runes of the name it matched, for highlighting.
+type queryTerm struct {
+	negate bool
+	match  func(entry fileEntry, name string) (positions []int, ok bool)
+}
+
+// queryToken is a single word, phrase or regex of a filter query.
+type queryToken struct {
+	text   string
+	negate bool
+	quoted bool
+	regex  bool
+}
+
+// queryFieldPattern splits a field predicate into field, operator and value.
+var queryFieldPattern = regexp.MustCompile(`^([a-zA-Z]+)(:|>=|<=|>|<|=)(.*)$`)
+
+// parseQuery parses a filter query. An empty query matches everything.
+func parseQuery(s string) (*filterQuery, error) {
+	tokens, err := tokenizeQuery(s)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &filterQuery{}
+	var words []string
+	for _, tok := range tokens {
+		var term queryTerm
+		switch {
+		case tok.regex:
+			term, err = regexTerm(tok.text)
+		case tok.quoted:
+			term = phraseTerm(tok.text)
+		default:
+			parts := queryFieldPattern.FindStringSubmatch(tok.text)
+			if parts == nil {
+				if tok.negate {
+					q.exclude = append(q.exclude, strings.ToLower(tok.text))
+				} else {
+					words = append(words, tok.text)
+				}
+				continue
+			}
+			term, err = fieldTerm(strings.ToLower(parts[1]), parts[2], strings.Trim(parts[3], `"`))
+		}
+		if err != nil {
+			return nil, err
+		}
+		term.negate = tok.negate
+		q.terms = append(q.terms, term)
+	}
+	q.text = strings.Join(words, " ")
+
+	return q, nil
+}
+
+// tokenizeQuery splits s on spaces, keeping quoted phrases and /regexes/
+// together.
+func tokenizeQuery(s string) ([]queryToken, error) {
+	var tokens []queryToken
+	for i := 0; i < len(s); {
+		if s[i] == ' ' || s[i] == '\t' {
+			i++
+			continue
+		}
+
+		tok := queryToken{}
+		if s[i] == '-' {
+			tok.negate = true
+			i++
+			if i == len(s) || s[i] == ' ' {
+				// A lone "-" negates nothing.
+				continue
+			}
+		}
+
+		switch s[i] {
+		case '"':
+			end := strings.IndexByte(s[i+1:], '"')
+			if end < 0 {
+				return nil, errors.New("unterminated quote")
+			}
+			tok.text, tok.quoted = s[i+1:i+1+end], true
+			i += end + 2
+		case '/':
+			end := regexEnd(s, i+1)
+			if end < 0 {
+				return nil, errors.New("unterminated regex, expected closing /")
+			}
+			tok.text, tok.regex = s[i+1:end], true
+			i = end + 1
+		default:
+			start := i
+			inQuote := false
+			for i < len(s) && (inQuote || (s[i] != ' ' && s[i] != '\t')) {
+				if s[i] == '"' {
+					inQuote = !inQuote
+				}
+				i++
+			}
+			if inQuote {
+				return nil, errors.New("unterminated quote")
+			}
+			tok.text = s[start:i]
+		}
+		tokens = append(tokens, tok)
+	}
+	return tokens, nil
+}
+
+// regexEnd returns the index of the "/" closing a regex that starts at i,
+// skipping escaped slashes, or -1.
+func regexEnd(s string, i int) int {
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '/':
+			return i
+		}
+	}
+	return -1
+}
+
+func regexTerm(expr string) (queryTerm, error) {
+	re, err := regexp.Compile("(?i)" + strings.ReplaceAll(expr, `\/`, "/"))
+	if err != nil {
+		var syntaxErr *syntax.Error
+		if errors.As(err, &syntaxErr) {
+			return queryTerm{}, fmt.Errorf("invalid regex /%s/: %s", expr, syntaxErr.Code)
+		}
+		return queryTerm{}, fmt.Errorf("invalid regex /%s/: %w", expr, err)
+	}
+	return queryTerm{match: func(_ fileEntry, name string) ([]int, bool) {
+		loc := re.FindStringIndex(name)
+		if loc == nil {
+			return nil, false
+		}
+		return runeRange(name, loc[0], loc[1]), true
+	}}, nil
+}
+
+func phraseTerm(phrase string) queryTerm {
+	return queryTerm{match: func(_ fileEntry, name string) ([]int, bool) {
+		return substringMatch(name, phrase)
+	}}
+}
+
+// runeRange returns the rune positions covered by the bytes [start, end) of s.
+func runeRange(s string, start, end int) []int {
+	first := utf8.RuneCountInString(s[:start])
+	positions := make([]int, utf8.RuneCountInString(s[start:end]))
+	for k := range positions {
+		positions[k] = first + k
+	}
+	return positions
+}
+
+// fieldTerm builds the predicate for field, compared with value by op.
+func fieldTerm(field, op, value string) (queryTerm, error) {
+	if value == "" {
+		return queryTerm{}, fmt.Errorf("%s%s needs a value", field, op)
+	}
+
+	switch field {
+	case "ext":
+		if op != ":" && op != "=" {
+			return queryTerm{}, fmt.Errorf("ext only supports ext:%s", value)
+		}
+		exts := strings.Split(strings.ToLower(value), ",")
+		return queryTerm{match: func(_ fileEntry, name string) ([]int, bool) {
+			ext := strings.ToLower(strings.TrimPrefix(path.Ext(name), "."))
+			for _, want := range exts {
+				if ext != "" && ext == strings.TrimPrefix(want, ".") {
+					return nil, true
+				}
+			}
+			return nil, false
+		}}, nil
+
+	case "size":
+		size := parseSize(value)
+		if size < 0 {
+			return queryTerm{}, fmt.Errorf("invalid size %q", value)
+		}
+		return queryTerm{match: func(entry fileEntry, _ string) ([]int, bool) {
+			return nil, entry.Size >= 0 && compareInt(entry.Size, op, size)
+		}}, nil
+
+	case "date":
+		start, end, err := parseDateRange(value)
+		if err != nil {
+			return queryTerm{}, err
+		}
+		return queryTerm{match: func(entry fileEntry, _ string) ([]int, bool) {
+			t := entry.ModTime
+			if t.IsZero() {
+				return nil, false
+			}
+			switch op {
+			case ">":
+				return nil, !t.Before(end)
+			case ">=":
+				return nil, !t.Before(start)
+			case "<":
+				return nil, t.Before(start)
+			case "<=":
+				return nil, t.Before(end)
+			default:
+				return nil, !t.Before(start) && t.Before(end)
+			}
+		}}, nil
+
+	case "region", "tag":
+		if op != ":" && op != "=" {
+			return queryTerm{}, fmt.Errorf("%s only supports %s:%s", field, field, value)
+		}
+		brackets := field == "tag"
+		return queryTerm{match: func(_ fileEntry, name string) ([]int, bool) {
+			return nil, hasNameTag(name, value, brackets)
+		}}, nil
+	}
+
+	return queryTerm{}, fmt.Errorf("unknown field %q (use ext, size, date, region or tag)", field)
+}
+
+func compareInt(a int64, op string, b int64) bool {
+	switch op {
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	default:
+		return a == b
+	}
+}
+
+// parseDateRange parses a year, month or day and returns the period it
+// covers.
+func parseDateRange(value string) (start, end time.Time, err error) {
+	for _, p := range []struct {
+		layout string
+		years  int
+		months int
+		days   int
+	}{
+		{"2006-01-02", 0, 0, 1},
+		{"2006-01", 0, 1, 0},
+		{"2006", 1, 0, 0},
+	} {
+		if start, err := time.Parse(p.layout, value); err == nil {
+			return start, start.AddDate(p.years, p.months, p.days), nil
+		}
+	}
+	return start, end, fmt.Errorf("invalid date %q, expected YYYY, YYYY-MM or YYYY-MM-DD", value)
+}
+
+// hasNameTag reports whether one of the parenthesised groups in name, such
+// as "(USA, Australia)", lists value. With brackets, groups in square
+// brackets count too.
+func hasNameTag(name, value string, brackets bool) bool {
+	for _, group := range nameTags(name, brackets) {
+		for _, item := range strings.Split(group, ",") {
+			if strings.EqualFold(strings.TrimSpace(item), value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nameTags returns the contents of the parenthesised groups in name, and of
+// the square-bracketed ones if brackets is set.
+func nameTags(name string, brackets bool) []string {
+	var tags []string
+	for {
+		open := strings.IndexAny(name, "([")
+		if open < 0 {
+			return tags
+		}
+		closer := ")"
+		if name[open] == '[' {
+			closer = "]"
+		}
+		end := strings.Index(name[open:], closer)
+		if end < 0 {
+			return tags
+		}
+		if closer == ")" || brackets {
+			tags = append(tags, name[open+1:open+end])
+		}
+		name = name[open+end+1:]
+	}
+}
+
+// match reports whether entry satisfies the query. score ranks fuzzy matches
+// of the plain words and positions are the runes of the name to highlight.
+func (q *filterQuery) match(entry fileEntry, substring bool) (score int, positions []int, ok bool) {
+	name := strings.TrimSuffix(entry.Name, "/")
+
+	if q.text != "" {
+		if substring {
+			positions, ok = substringMatch(name, q.text)
+		} else {
+			score, positions, ok = fuzzyMatch(name, q.text)
+		}
+		if !ok {
+			return 0, nil, false
+		}
+	}
+
+	lower := strings.ToLower(name)
+	for _, word := range q.exclude {
+		if strings.Contains(lower, word) {
+			return 0, nil, false
+		}
+	}
+
+	for _, term := range q.terms {
+		termPositions, matched := term.match(entry, name)
+		if matched == term.negate {
+			return 0, nil, false
+		}
+		if !term.negate {
+			positions = append(positions, termPositions...)
+		}
+	}
+
+	return score, positions, true
+}
@@ -0,0 +1,165 @@
+package myrient_browser
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseQuery(t *testing.T) {
+	game := fileEntry{
+		Name:    "Mario Kart DS (USA, Australia) (v1.1) [b].zip",
+		Size:    64 << 20,
+		ModTime: time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC),
+	}
+	beta := fileEntry{
+		Name:    "Mario Kart DS (Europe) (Beta).nds",
+		Size:    512 << 10,
+		ModTime: time.Date(2023, 12, 31, 23, 59, 0, 0, time.UTC),
+	}
+	undated := fileEntry{Name: "Tetris (Japan).7z", Size: -1}
+
+	tests := []struct {
+		query string
+		entry fileEntry
+		want  bool
+	}{
+		{"", game, true},
+		{"mario", game, true},
+		{"mkds", game, true},
+		{"zelda", game, false},
+		{"ext:zip", game, true},
+		{"ext:nds,zip", beta, true},
+		{"ext:.zip", game, true},
+		{"ext:zip", beta, false},
+		{"-ext:zip", beta, true},
+		{"size>1MB", game, true},
+		{"size>1MB", beta, false},
+		{"size<=512K", beta, true},
+		{"size=64M", game, true},
+		{"size>0", undated, false},
+		{"date>=2024", game, true},
+		{"date>=2024", beta, false},
+		{"date<2024", beta, true},
+		{"date:2024-03", game, true},
+		{"date:2024-03-16", game, false},
+		{"date>2023-12-31", game, true},
+		{"date<=2023-12-31", beta, true},
+		{"date:2024", undated, false},
+		{"region:usa", game, true},
+		{"region:Australia", game, true},
+		{"region:europe", game, false},
+		{"region:b", game, false},
+		{"tag:b", game, true},
+		{"tag:v1.1", game, true},
+		{"-beta", game, true},
+		{"-beta", beta, false},
+		{"mario -beta", beta, false},
+		{`"kart ds"`, game, true},
+		{`"kart  ds"`, game, false},
+		{`-"(beta)"`, beta, false},
+		{`/\bv1\.1\b/`, game, true},
+		{`/^mario.*\.nds$/`, beta, true},
+		{`-/\(beta\)/`, beta, false},
+		{`/a\/b/`, game, false},
+		{`region:"USA"`, game, true},
+		{"mario ext:zip size>1MB region:USA -beta date>=2024-01", game, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query+"/"+tt.entry.Name, func(t *testing.T) {
+			q, err := parseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("parseQuery(%q) failed: %v", tt.query, err)
+			}
+			if _, _, ok := q.match(tt.entry, false); ok != tt.want {
+				t.Errorf("parseQuery(%q).match(%q) = %v, want %v", tt.query, tt.entry.Name, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`"kart`, "unterminated quote"},
+		{`region:"USA`, "unterminated quote"},
+		{"/mario", "unterminated regex, expected closing /"},
+		{"/(/", "invalid regex /(/: missing closing )"},
+		{"size>big", `invalid size "big"`},
+		{"size>", "size> needs a value"},
+		{"date>=yesterday", `invalid date "yesterday", expected YYYY, YYYY-MM or YYYY-MM-DD`},
+		{"ext>zip", "ext only supports ext:zip"},
+		{"region>USA", "region only supports region:USA"},
+		{"color:red", `unknown field "color" (use ext, size, date, region or tag)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := parseQuery(tt.query)
+			if err == nil {
+				t.Fatalf("parseQuery(%q) succeeded, want error %q", tt.query, tt.want)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("parseQuery(%q) error = %q, want %q", tt.query, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterQueryPositions(t *testing.T) {
+	tests := []struct {
+		query     string
+		name      string
+		substring bool
+		want      []int
+	}{
+		{"kart", "Mario Kart", true, []int{6, 7, 8, 9}},
+		{"mk", "Mario Kart", false, []int{0, 6}},
+		{`"art"`, "Mario Kart", false, []int{7, 8, 9}},
+		{"/k.r/", "Mario Kart", false, []int{6, 7, 8}},
+		{"ext:zip", "Mario Kart.zip", false, nil},
+		{"kart -/mario/", "Super Kart", true, []int{6, 7, 8, 9}},
+		{"/é/", "Pokémon", false, []int{3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := parseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("parseQuery(%q) failed: %v", tt.query, err)
+			}
+			_, positions, ok := q.match(fileEntry{Name: tt.name}, tt.substring)
+			if !ok {
+				t.Fatalf("parseQuery(%q) doesn't match %q", tt.query, tt.name)
+			}
+			if !slices.Equal(positions, tt.want) {
+				t.Errorf("positions = %v, want %v", positions, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenizeQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []queryToken
+	}{
+		{"", nil},
+		{"  mario   kart ", []queryToken{{text: "mario"}, {text: "kart"}}},
+		{"-beta - proto", []queryToken{{text: "beta", negate: true}, {text: "proto"}}},
+		{`"kart ds" -"(beta)"`, []queryToken{{text: "kart ds", quoted: true}, {text: "(beta)", negate: true, quoted: true}}},
+		{`region:"New Zealand"`, []queryToken{{text: `region:"New Zealand"`}}},
+		{`/a\/b c/ x`, []queryToken{{text: `a\/b c`, regex: true}, {text: "x"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := tokenizeQuery(tt.query)
+			if err != nil {
+				t.Fatalf("tokenizeQuery(%q) failed: %v", tt.query, err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("tokenizeQuery(%q) = %+v, want %+v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
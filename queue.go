@@ -0,0 +1,459 @@
+package myrient_browser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const queueFileName = "queue.json"
+
+// Persisted states of a queue item. While the queue is being downloaded the
+// panel shows more detailed states derived from the running jobs.
+const (
+	queueStatusQueued = "queued"
+	queueStatusDone   = "done"
+	queueStatusFailed = "failed"
+)
+
+// queueItem is a file or directory waiting in the download queue. Each item
+// remembers the remote directory it was added from, the local directory it
+// is downloaded into and how it is extracted, as set by the toggles and
+// profile in effect when it was added.
+type queueItem struct {
+	ID        int64     `json:"id"`
+	BasePath  string    `json:"base_path"`
+	Entry     fileEntry `json:"entry"`
+	OutputDir string    `json:"output_dir"`
+	Extract   string    `json:"extract,omitempty"`
+	DeleteZip *bool     `json:"delete_zip,omitempty"`
+	Status    string    `json:"status"`
+}
+
+func (it *queueItem) isDir() bool {
+	return strings.HasSuffix(it.Entry.Path, "/")
+}
+
+// options returns opts with the item's extraction settings. Items queued
+// before these were recorded keep the settings of opts.
+func (it *queueItem) options(opts Options) Options {
+	p := Profile{Extract: it.Extract, DeleteZip: it.DeleteZip}
+	return p.apply(opts)
+}
+
+// localDirFor returns the local directory mirroring the remote directory
+// dir, which is the item's base path or one below it.
+func (it *queueItem) localDirFor(dir string) string {
+	rel := strings.TrimPrefix(dir, it.BasePath)
+	if decoded, err := url.PathUnescape(rel); err == nil {
+		rel = decoded
+	}
+	return filepath.Join(it.OutputDir, filepath.FromSlash(rel))
+}
+
+// downloadQueue is the persistent list of queued downloads.
+type downloadQueue struct {
+	NextID int64       `json:"next_id"`
+	Items  []queueItem `json:"items"`
+}
+
+// queueRun links the jobs of a queue download to the items they came from.
+type queueRun struct {
+	stats *downloadStats
+	items map[int64]bool
+	// owners holds the item ID of each job, in job order. It is nil until
+	// queued directories have been listed.
+	owners []int64
+}
+
+// DefaultQueuePath returns the location of the download queue, next to the
+// state file.
+func DefaultQueuePath() string {
+	return stateFile(queueFileName)
+}
+
+// loadQueue reads the queue at path, returning an empty queue if there is
+// none.
+func loadQueue(path string) (*downloadQueue, error) {
+	q := &downloadQueue{}
+	if path == "" {
+		return q, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return q, err
+	}
+	if err := json.Unmarshal(data, q); err != nil {
+		return &downloadQueue{}, fmt.Errorf("failed to parse queue %s: %w", path, err)
+	}
+	return q, nil
+}
+
+// writeQueue atomically replaces the queue file at path.
+func writeQueue(path string, q *downloadQueue) error {
+	if path == "" {
+		return nil
+	}
+
+	return writeFileAtomic(path, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(q)
+	})
+}
+
+// saveQueue persists the queue, recording how far a running download got.
+func (m *Model) saveQueue() {
+	saved := downloadQueue{NextID: m.queue.NextID, Items: make([]queueItem, len(m.queue.Items))}
+	for i, it := range m.queue.Items {
+		saved.Items[i] = it
+		switch status := m.queueItemStatus(&it); status {
+		case queueStatusDone, queueStatusFailed:
+			saved.Items[i].Status = status
+		default:
+			saved.Items[i].Status = queueStatusQueued
+		}
+	}
+
+	if err := writeQueue(m.opts.QueuePath, &saved); err != nil {
+		m.lastError = fmt.Sprintf("failed to save queue: %v", err)
+	}
+}
+
+// queueIndex returns the position of the entry at path in the remote
+// directory basePath in the queue, or -1.
+func (m *Model) queueIndex(basePath, path string) int {
+	for i, it := range m.queue.Items {
+		if it.BasePath == basePath && it.Entry.Path == path {
+			return i
+		}
+	}
+	return -1
+}
+
+// isQueued reports whether the entry at path in basePath is in the queue.
+func (m *Model) isQueued(basePath, path string) bool {
+	return m.queueIndex(basePath, path) >= 0
+}
+
+// addToQueue appends the entries of the remote directory basePath that
+// aren't queued yet and returns how many were added.
+func (m *Model) addToQueue(basePath string, entries []fileEntry) int {
+	added := 0
+	for _, entry := range entries {
+		if entry.Path == "../" || m.isQueued(basePath, entry.Path) {
+			continue
+		}
+		opts := m.optionsFor(basePath)
+		if strings.HasSuffix(entry.Path, "/") {
+			// A queued directory is downloaded with the profile bound to it.
+			opts = m.optionsFor(basePath + entry.Path)
+		}
+		// The queue may be resumed from another working directory.
+		outputDir := outputDirFor(basePath, opts)
+		if abs, err := filepath.Abs(outputDir); err == nil {
+			outputDir = abs
+		}
+		deleteZip := opts.DeleteZip
+		m.queue.NextID++
+		m.queue.Items = append(m.queue.Items, queueItem{
+			ID:        m.queue.NextID,
+			BasePath:  basePath,
+			Entry:     entry,
+			OutputDir: outputDir,
+			Extract:   extractMode(opts),
+			DeleteZip: &deleteZip,
+			Status:    queueStatusQueued,
+		})
+		added++
+	}
+	if added > 0 {
+		m.saveQueue()
+	}
+	return added
+}
+
+// enqueueSelection adds the marked entries, or the entry under the cursor if
+// none are marked, to the queue.
+func (m *Model) enqueueSelection() {
+	var entries []fileEntry
+	if len(m.marked) > 0 {
+		files, dirs := m.markedEntries()
+		entries = append(dirs, files...)
+		m.clearMarks()
+	} else if m.cursor < len(m.filtered) {
+		entries = []fileEntry{m.entries[m.filtered[m.cursor]]}
+	}
+
+	added := m.addToQueue(m.currentPath, entries)
+	m.status = fmt.Sprintf("Added %d to queue (%d queued) - press %s to view", added, len(m.queue.Items), keyHint(m.keys.QueuePanel))
+}
+
+// toggleQueued adds the entry to the queue, or removes it if it is already
+// queued.
+func (m *Model) toggleQueued(basePath string, entry fileEntry) {
+	name := strings.TrimSuffix(entry.Name, "/")
+	if i := m.queueIndex(basePath, entry.Path); i >= 0 {
+		m.queue.Items = append(m.queue.Items[:i], m.queue.Items[i+1:]...)
+		m.saveQueue()
+		m.status = fmt.Sprintf("Removed %s from queue (%d queued)", name, len(m.queue.Items))
+		return
+	}
+	m.addToQueue(basePath, []fileEntry{entry})
+	m.status = fmt.Sprintf("Queued %s (%d queued)", name, len(m.queue.Items))
+}
+
+// moveQueueItem moves the item under the queue cursor by delta places.
+func (m *Model) moveQueueItem(delta int) {
+	i, j := m.queueCursor, m.queueCursor+delta
+	if i >= len(m.queue.Items) || j < 0 || j >= len(m.queue.Items) {
+		return
+	}
+	m.queue.Items[i], m.queue.Items[j] = m.queue.Items[j], m.queue.Items[i]
+	m.queueCursor = j
+	m.saveQueue()
+}
+
+// removeQueueItem removes the item under the queue cursor.
+func (m *Model) removeQueueItem() {
+	if m.queueCursor >= len(m.queue.Items) {
+		return
+	}
+	m.queue.Items = append(m.queue.Items[:m.queueCursor], m.queue.Items[m.queueCursor+1:]...)
+	m.queueCursor = max(min(m.queueCursor, len(m.queue.Items)-1), 0)
+	m.saveQueue()
+}
+
+// clearFinished removes the items that have been downloaded.
+func (m *Model) clearFinished() {
+	var kept []queueItem
+	for _, it := range m.queue.Items {
+		if m.queueItemStatus(&it) != queueStatusDone {
+			kept = append(kept, it)
+		}
+	}
+	removed := len(m.queue.Items) - len(kept)
+	m.queue.Items = kept
+	m.queueCursor = max(min(m.queueCursor, len(m.queue.Items)-1), 0)
+	m.saveQueue()
+	m.status = fmt.Sprintf("Removed %d finished items", removed)
+}
+
+// queueItemStatus describes the state of it, following its jobs while the
+// queue is being downloaded.
+func (m *Model) queueItemStatus(it *queueItem) string {
+	run := m.queueRun
+	if run == nil || !run.items[it.ID] {
+		return it.Status
+	}
+
+	stats := run.stats
+	running := m.downloading && stats == m.downloadStats
+	if running && stats.crawling {
+		return "listing"
+	}
+	if running && stats.scanning {
+		return "scanning"
+	}
+	if run.owners == nil {
+		return queueStatusQueued
+	}
+
+	var total, finished, failed, active int
+	for i, owner := range run.owners {
+		if owner != it.ID {
+			continue
+		}
+		total++
+		if i >= len(stats.files) {
+			continue
+		}
+		switch atomic.LoadInt32(&stats.files[i].status) {
+		case fileActive:
+			active++
+		case fileDone, fileSkipped:
+			finished++
+		case fileFailed:
+			finished++
+			failed++
+		}
+	}
+
+	switch {
+	case running && (active > 0 || (finished > 0 && finished < total)):
+		return fmt.Sprintf("downloading %d/%d", finished, total)
+	case finished < total:
+		return queueStatusQueued
+	case failed > 0:
+		return queueStatusFailed
+	default:
+		return queueStatusDone
+	}
+}
+
+// settleQueue records the outcome of a finished queue download in its items.
+func (m *Model) settleQueue() {
+	if m.queueRun == nil || m.downloading {
+		return
+	}
+	for i := range m.queue.Items {
+		it := &m.queue.Items[i]
+		if m.queueRun.items[it.ID] {
+			it.Status = m.queueItemStatus(it)
+		}
+	}
+	m.queueRun = nil
+	m.saveQueue()
+}
+
+// startQueue downloads every queued item that hasn't been downloaded yet,
+// in queue order. Queued directories are listed recursively first.
+func (m *Model) startQueue() tea.Cmd {
+	if m.downloadBusy() {
+		return nil
+	}
+	m.settleQueue()
+
+	var items []queueItem
+	for _, it := range m.queue.Items {
+		if it.Status != queueStatusDone {
+			items = append(items, it)
+		}
+	}
+	if len(items) == 0 {
+		m.status = "Nothing left to download in the queue"
+		return nil
+	}
+	return m.downloadQueueItems(items)
+}
+
+// finishQueue settles a finished download and goes on with the items that
+// were queued while it ran.
+func (m *Model) finishQueue() tea.Cmd {
+	m.settleQueue()
+
+	var items []queueItem
+	for _, it := range m.queue.Items {
+		if it.ID > m.queueSeen && it.Status == queueStatusQueued {
+			items = append(items, it)
+		}
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	return m.downloadQueueItems(items)
+}
+
+// downloadQueueItems downloads items in order, linking the jobs to them.
+func (m *Model) downloadQueueItems(items []queueItem) tea.Cmd {
+	hasDirs := false
+	for _, it := range items {
+		hasDirs = hasDirs || it.isDir()
+	}
+
+	run := &queueRun{items: map[int64]bool{}}
+	for _, it := range items {
+		run.items[it.ID] = true
+	}
+
+	if !hasDirs {
+		var files []fileInfo
+		for _, it := range items {
+			info, err := newFileInfo(it.BasePath, it.OutputDir, it.Entry, it.options(m.downloadOptions()))
+			if err != nil {
+				m.lastError = err.Error()
+				return nil
+			}
+			files = append(files, info)
+			run.owners = append(run.owners, it.ID)
+		}
+		cmd := m.startDownloadInfos(files, fmt.Sprintf("%d queued files", len(files)))
+		run.stats = m.downloadStats
+		m.queueRun = run
+		return cmd
+	}
+
+	m.beginDownload(m.downloadOptions(), &downloadStats{
+		scanning: true,
+		crawling: true,
+	})
+	m.status = ""
+	run.stats = m.downloadStats
+	m.queueRun = run
+
+	return tea.Batch(expandQueue(items, m.downloadStats, m.ctx, m.jobOpts), tickCmd())
+}
+
+// expandQueue builds the download jobs for items with their own extraction
+// settings, listing queued directories recursively up to opts.MaxDepth
+// levels. Files found in a directory must pass the filters of the profile
+// bound to it.
+func expandQueue(items []queueItem, stats *downloadStats, ctx context.Context, opts Options) tea.Cmd {
+	return func() tea.Msg {
+		var files []fileInfo
+		var owners []int64
+
+		depth := -1
+		if opts.MaxDepth > 0 {
+			depth = opts.MaxDepth - 1
+		}
+
+		for _, it := range items {
+			itemOpts := it.options(opts)
+			if !it.isDir() {
+				info, err := newFileInfo(it.BasePath, it.OutputDir, it.Entry, itemOpts)
+				if err != nil {
+					return errMsg{err: err, stats: stats}
+				}
+				files = append(files, info)
+				owners = append(owners, it.ID)
+				atomic.AddInt32(&stats.filesFound, 1)
+				continue
+			}
+
+			_, profile := opts.profileFor(it.BasePath + it.Entry.Path)
+			atomic.AddInt32(&stats.dirsCrawled, 1)
+			err := walkDirectory(opts, it.BasePath+it.Entry.Path, depth, func(dir string, entry fileEntry) error {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				if strings.HasSuffix(entry.Path, "/") {
+					atomic.AddInt32(&stats.dirsCrawled, 1)
+					return nil
+				}
+				if profile != nil && !profile.allows(entry.Name) {
+					return nil
+				}
+				info, err := newFileInfo(dir, it.localDirFor(dir), entry, itemOpts)
+				if err != nil {
+					return err
+				}
+				files = append(files, info)
+				owners = append(owners, it.ID)
+				atomic.AddInt32(&stats.filesFound, 1)
+				return nil
+			})
+			if ctx.Err() != nil {
+				return crawlCompleteMsg{stats: stats}
+			}
+			if err != nil {
+				return errMsg{err: err, stats: stats}
+			}
+		}
+
+		return crawlCompleteMsg{stats: stats, files: files, owners: owners}
+	}
+}
@@ -0,0 +1,101 @@
+package myrient_browser
+
+import (
+	"context"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestQueueItemOptions(t *testing.T) {
+	yes, no := true, false
+
+	type settings struct{ extract, toFolder, deleteZip bool }
+	tests := []struct {
+		name string
+		item queueItem
+		want settings
+	}{
+		{"recorded before extraction settings", queueItem{}, settings{true, false, true}},
+		{"no extraction", queueItem{Extract: extractNone, DeleteZip: &no}, settings{false, false, false}},
+		{"flat", queueItem{Extract: extractFlat, DeleteZip: &no}, settings{true, false, false}},
+		{"into a folder", queueItem{Extract: extractFolder, DeleteZip: &yes}, settings{true, true, true}},
+		{"only delete zip recorded", queueItem{DeleteZip: &no}, settings{true, false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.AutoExtract, opts.ExtractToFolder, opts.DeleteZip = true, false, true
+
+			got := tt.item.options(opts)
+			if s := (settings{got.AutoExtract, got.ExtractToFolder, got.DeleteZip}); s != tt.want {
+				t.Errorf("options = %+v, want %+v", s, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddToQueueOutputDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	opts := DefaultOptions()
+	opts.OutputDir = "downloads"
+	m := InitialModel(opts)
+	m.addToQueue("Sub%20Dir/", []fileEntry{{Name: "a.zip", Path: "a.zip"}})
+
+	if want := filepath.Join(dir, "downloads", "Sub Dir"); m.queue.Items[0].OutputDir != want {
+		t.Errorf("OutputDir = %q, want %q", m.queue.Items[0].OutputDir, want)
+	}
+}
+
+func TestExpandQueue(t *testing.T) {
+	srv := httptest.NewServer(mirrorHandler(map[string]string{
+		"/files/Sub Dir/":      `[{"name":"a.zip","type":"file","size":4},{"name":"a.txt","type":"file","size":1},{"name":"Deep","type":"directory"}]`,
+		"/files/Sub Dir/Deep/": `[{"name":"b.zip","type":"file","size":2}]`,
+	}))
+	defer srv.Close()
+
+	no := false
+	opts := DefaultOptions()
+	opts.BaseURL = srv.URL + "/files/"
+	opts.AutoExtract, opts.ExtractToFolder, opts.DeleteZip = true, false, true
+	opts.Profiles = map[string]Profile{"zips": {Prefixes: []string{"Sub Dir/"}, Include: []string{"*.zip"}}}
+
+	out := t.TempDir()
+	items := []queueItem{
+		{ID: 1, BasePath: "Top/", Entry: fileEntry{Name: "c.zip", Path: "c.zip"}, OutputDir: filepath.Join(out, "top")},
+		{ID: 2, BasePath: "", Entry: fileEntry{Name: "Sub Dir/", Path: "Sub%20Dir/"}, OutputDir: filepath.Join(out, "sub"),
+			Extract: extractFolder, DeleteZip: &no},
+	}
+
+	msg := expandQueue(items, &downloadStats{}, context.Background(), opts)()
+	done, ok := msg.(crawlCompleteMsg)
+	if !ok {
+		t.Fatalf("expandQueue returned %#v, want crawlCompleteMsg", msg)
+	}
+
+	want := []struct {
+		owner    int64
+		url      string
+		path     string
+		settings [3]bool
+	}{
+		{1, opts.BaseURL + "Top/c.zip", filepath.Join(out, "top", "c.zip"), [3]bool{true, false, true}},
+		{2, opts.BaseURL + "Sub%20Dir/a.zip", filepath.Join(out, "sub", "Sub Dir", "a.zip"), [3]bool{true, true, false}},
+		{2, opts.BaseURL + "Sub%20Dir/Deep/b.zip", filepath.Join(out, "sub", "Sub Dir", "Deep", "b.zip"), [3]bool{true, true, false}},
+	}
+	if len(done.files) != len(want) || !slices.Equal(done.owners, []int64{1, 2, 2}) {
+		t.Fatalf("expandQueue found %d files owned by %v, want %d owned by [1 2 2]", len(done.files), done.owners, len(want))
+	}
+	for i, w := range want {
+		f := done.files[i]
+		if f.url != w.url || f.path != w.path {
+			t.Errorf("file %d = %s to %s, want %s to %s", i, f.url, f.path, w.url, w.path)
+		}
+		if s := [3]bool{f.extract, f.extractToFolder, f.deleteZip}; s != w.settings {
+			t.Errorf("file %d settings = %v, want %v", i, s, w.settings)
+		}
+	}
+}
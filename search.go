@@ -0,0 +1,143 @@
+package myrient_browser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxSearchResults caps how many matches are ranked and shown.
+const maxSearchResults = 500
+
+type searchMatch struct {
+	entry *indexEntry
+	score int
+}
+
+// searchTerms splits a query into lowercase words, ignoring punctuation.
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// scoreEntry ranks e against the query terms. Every term must occur in the
+// entry's path; terms found in the name, especially at the start of a word,
+// count for more than terms only found in a parent directory.
+func scoreEntry(e *indexEntry, terms []string, phrase string) (int, bool) {
+	score := 0
+	for _, term := range terms {
+		i := strings.LastIndex(e.lower, term)
+		if i < 0 {
+			return 0, false
+		}
+		if i < e.nameAt {
+			score += 2
+			continue
+		}
+		score += 10
+		if i == e.nameAt || !isWordRune(e.lower[i-1]) {
+			score += 5
+		}
+	}
+
+	name := e.lower[e.nameAt:]
+	if strings.HasPrefix(name, phrase) {
+		score += 20
+	} else if strings.Contains(name, phrase) {
+		score += 10
+	}
+	// Prefer short names, which match the query more closely.
+	score -= len(name) / 8
+
+	return score, true
+}
+
+func isWordRune(b byte) bool {
+	return b >= 0x80 || unicode.IsLetter(rune(b)) || unicode.IsDigit(rune(b))
+}
+
+// searchEntries returns the best matches for query, highest score first.
+func searchEntries(entries []indexEntry, query string) []*indexEntry {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+	phrase := strings.Join(terms, " ")
+
+	var matches []searchMatch
+	for i := range entries {
+		if score, ok := scoreEntry(&entries[i], terms, phrase); ok {
+			matches = append(matches, searchMatch{entry: &entries[i], score: score})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		return a.entry.lower < b.entry.lower
+	})
+
+	results := make([]*indexEntry, 0, min(len(matches), maxSearchResults))
+	for _, match := range matches[:min(len(matches), maxSearchResults)] {
+		results = append(results, match.entry)
+	}
+	return results
+}
+
+// updateSearch reruns the search for the current query.
+func (m *Model) updateSearch() {
+	m.searchResults = nil
+	if m.index != nil {
+		m.searchResults = searchEntries(m.index.Entries, m.searchInput.Value())
+	}
+	m.searchCursor = 0
+	m.searchOffset = 0
+}
+
+// moveSearchCursor moves the cursor in the search results by delta, keeping
+// it in view.
+func (m *Model) moveSearchCursor(delta int) {
+	m.searchCursor = max(min(m.searchCursor+delta, len(m.searchResults)-1), 0)
+	if m.searchCursor < m.searchOffset {
+		m.searchOffset = m.searchCursor
+	} else if m.viewport.height > 0 && m.searchCursor >= m.searchOffset+m.viewport.height {
+		m.searchOffset = m.searchCursor - m.viewport.height + 1
+	}
+}
+
+// openSearchResult leaves search mode and opens e: a directory is entered,
+// a file is shown selected in the directory that holds it.
+func (m *Model) openSearchResult(e *indexEntry) tea.Cmd {
+	m.searching = false
+	m.searchInput.Blur()
+	if e.isDir() {
+		return m.navigateTo(e.Dir + e.Path)
+	}
+	m.jumpTo = e.Path
+	return m.navigateTo(e.Dir)
+}
+
+// indexSummary describes the loaded index and any crawl in progress for the
+// title bar.
+func (m *Model) indexSummary() string {
+	switch {
+	case m.indexer != nil && m.indexer.stopping:
+		return fmt.Sprintf("pausing: %d dirs, %d entries", m.indexDirs, len(m.index.Entries))
+	case m.indexer != nil:
+		return fmt.Sprintf("indexing: %d dirs, %d queued, %d entries",
+			m.indexDirs, m.indexPending, len(m.index.Entries))
+	case m.index == nil:
+		return "no index"
+	case !m.index.Complete:
+		return fmt.Sprintf("partial index: %d entries", len(m.index.Entries))
+	default:
+		return fmt.Sprintf("index: %d entries, %s", len(m.index.Entries), m.index.Updated.Format(time.DateOnly))
+	}
+}
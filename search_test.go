@@ -0,0 +1,101 @@
+package myrient_browser
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// searchFixture is a small index covering names, directories and ties.
+var searchFixture = []struct{ dir, name string }{
+	{"No-Intro/Nintendo%20-%20Game%20Boy/", "Tetris (World).zip"},
+	{"No-Intro/Nintendo%20-%20Game%20Boy/", "Super Mario Land (World).zip"},
+	{"No-Intro/Nintendo%20-%20NES/", "Tetris 2 (USA).zip"},
+	{"No-Intro/Nintendo%20-%20NES/", "Tetris (USA).zip"},
+	{"Redump/", "Tetris Collection/"},
+	{"Redump/Tetris%20Collection/", "Disc 1.zip"},
+	{"TOSEC/", "Pentetris.zip"},
+}
+
+func TestSearchEntries(t *testing.T) {
+	var entries []indexEntry
+	for _, f := range searchFixture {
+		entries = append(entries, newIndexEntry(f.dir, fileEntry{Name: f.name, Path: f.name}))
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"empty query", "", nil},
+		{"punctuation only", "()!", nil},
+		{"no match", "zelda", nil},
+		{
+			// The four names starting with the query tie and are ordered
+			// by path; a match inside a word and one in a parent
+			// directory come after them.
+			"name before directory", "tetris",
+			[]string{
+				"No-Intro/Nintendo - Game Boy/Tetris (World).zip",
+				"No-Intro/Nintendo - NES/Tetris (USA).zip",
+				"No-Intro/Nintendo - NES/Tetris 2 (USA).zip",
+				"Redump/Tetris Collection/",
+				"TOSEC/Pentetris.zip",
+				"Redump/Tetris Collection/Disc 1.zip",
+			},
+		},
+		{
+			"every term must match", "TETRIS usa",
+			[]string{"No-Intro/Nintendo - NES/Tetris (USA).zip", "No-Intro/Nintendo - NES/Tetris 2 (USA).zip"},
+		},
+		{"phrase", "tetris 2", []string{"No-Intro/Nintendo - NES/Tetris 2 (USA).zip"}},
+		{"terms in the path", "game boy, tetris", []string{"No-Intro/Nintendo - Game Boy/Tetris (World).zip"}},
+		{"term only in the path", "nes", []string{"No-Intro/Nintendo - NES/Tetris (USA).zip", "No-Intro/Nintendo - NES/Tetris 2 (USA).zip"}},
+		{"directory name", "collection", []string{"Redump/Tetris Collection/", "Redump/Tetris Collection/Disc 1.zip"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, e := range searchEntries(entries, tt.query) {
+				got = append(got, decodePath(e.Dir)+e.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("searchEntries(%q) =\n%s\nwant\n%s", tt.query, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestScoreEntry(t *testing.T) {
+	score := func(dir, name, query string) int {
+		t.Helper()
+		e := newIndexEntry(dir, fileEntry{Name: name, Path: name})
+		terms := searchTerms(query)
+		s, ok := scoreEntry(&e, terms, strings.Join(terms, " "))
+		if !ok {
+			t.Fatalf("%s%s doesn't match %q", dir, name, query)
+		}
+		return s
+	}
+
+	tests := []struct {
+		name          string
+		better, worse [3]string
+	}{
+		{"name over directory", [3]string{"A/", "Tetris.zip", "tetris"}, [3]string{"Tetris/", "Game.zip", "tetris"}},
+		{"word start over inside a word", [3]string{"A/", "Mario Land.zip", "land"}, [3]string{"A/", "Marioland.zip", "land"}},
+		{"prefix over contained phrase", [3]string{"A/", "Mario Kart.zip", "mario kart"}, [3]string{"A/", "Super Mario Kart.zip", "mario kart"}},
+		{"phrase over scattered terms", [3]string{"A/", "Mario Kart DS.zip", "kart ds"}, [3]string{"A/", "Kart Mario DS.zip", "kart ds"}},
+		{"shorter name", [3]string{"A/", "Tetris.zip", "tetris"}, [3]string{"A/", "Tetris (Europe) (En,Fr,De) (Rev 1).zip", "tetris"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := score(tt.better[0], tt.better[1], tt.better[2])
+			w := score(tt.worse[0], tt.worse[1], tt.worse[2])
+			if b <= w {
+				t.Errorf("%s scores %d, not more than %s with %d", tt.better[1], b, tt.worse[1], w)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package myrient_browser
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+type sortMode int
+
+const (
+	sortNameAsc sortMode = iota
+	sortNameDesc
+	sortSizeDesc
+	sortSizeAsc
+	sortDateDesc
+	sortDateAsc
+	sortExtension
+	numSortModes
+)
+
+func (s sortMode) String() string {
+	switch s {
+	case sortNameDesc:
+		return "name ↓"
+	case sortSizeDesc:
+		return "size ↓"
+	case sortSizeAsc:
+		return "size ↑"
+	case sortDateDesc:
+		return "newest"
+	case sortDateAsc:
+		return "oldest"
+	case sortExtension:
+		return "extension"
+	default:
+		return "name ↑"
+	}
+}
+
+// sortEntries orders entries in place. The parent entry stays first and
+// directories are kept ahead of files in every mode.
+func sortEntries(entries []fileEntry, mode sortMode) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+
+		if a.Path == "../" || b.Path == "../" {
+			return a.Path == "../" && b.Path != "../"
+		}
+		aDir, bDir := strings.HasSuffix(a.Path, "/"), strings.HasSuffix(b.Path, "/")
+		if aDir != bDir {
+			return aDir
+		}
+
+		switch mode {
+		case sortNameDesc:
+			return lessName(b, a)
+		case sortSizeDesc:
+			if a.Size != b.Size {
+				return a.Size > b.Size
+			}
+		case sortSizeAsc:
+			if a.Size != b.Size {
+				return a.Size < b.Size
+			}
+		case sortDateDesc:
+			if !a.ModTime.Equal(b.ModTime) {
+				return a.ModTime.After(b.ModTime)
+			}
+		case sortDateAsc:
+			if !a.ModTime.Equal(b.ModTime) {
+				return a.ModTime.Before(b.ModTime)
+			}
+		case sortExtension:
+			aExt, bExt := strings.ToLower(path.Ext(a.Name)), strings.ToLower(path.Ext(b.Name))
+			if aExt != bExt {
+				return aExt < bExt
+			}
+		}
+		return lessName(a, b)
+	})
+}
+
+func lessName(a, b fileEntry) bool {
+	return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+}
+
+// applySort re-sorts the entries and filter results for the current mode,
+// keeping the cursor on the same entry.
+func (m *Model) applySort() {
+	m.replaceEntries(m.entries)
+}
+
+// replaceEntries swaps in a new listing of the current directory, keeping
+// the filter and the cursor on the same entry where possible.
+func (m *Model) replaceEntries(entries []fileEntry) {
+	var selected string
+	if m.cursor < len(m.filtered) {
+		selected = m.entries[m.filtered[m.cursor]].Path
+	}
+
+	m.entries = entries
+	sortEntries(m.entries, m.sortMode)
+	m.updateFilter()
+
+	for i, idx := range m.filtered {
+		if m.entries[idx].Path == selected {
+			m.setCursor(i)
+			break
+		}
+	}
+}
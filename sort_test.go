@@ -0,0 +1,61 @@
+package myrient_browser
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSortEntries(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+	entries := []fileEntry{
+		{Name: "b.zip", Path: "b.zip", Size: 30, ModTime: day(2)},
+		{Name: "Zeta/", Path: "Zeta/", Size: -1, ModTime: day(1)},
+		{Name: "A.iso", Path: "A.iso", Size: 20, ModTime: day(3)},
+		{Name: "..", Path: "../", Size: -1},
+		{Name: "c.7z", Path: "c.7z", Size: 20, ModTime: day(1)},
+		{Name: "alpha/", Path: "alpha/", Size: -1, ModTime: day(4)},
+	}
+
+	tests := []struct {
+		mode sortMode
+		want []string
+	}{
+		{sortNameAsc, []string{"..", "alpha/", "Zeta/", "A.iso", "b.zip", "c.7z"}},
+		{sortNameDesc, []string{"..", "Zeta/", "alpha/", "c.7z", "b.zip", "A.iso"}},
+		{sortSizeDesc, []string{"..", "alpha/", "Zeta/", "b.zip", "A.iso", "c.7z"}},
+		{sortSizeAsc, []string{"..", "alpha/", "Zeta/", "A.iso", "c.7z", "b.zip"}},
+		{sortDateDesc, []string{"..", "alpha/", "Zeta/", "A.iso", "b.zip", "c.7z"}},
+		{sortDateAsc, []string{"..", "Zeta/", "alpha/", "c.7z", "b.zip", "A.iso"}},
+		{sortExtension, []string{"..", "alpha/", "Zeta/", "c.7z", "A.iso", "b.zip"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			sorted := slices.Clone(entries)
+			sortEntries(sorted, tt.mode)
+			var names []string
+			for _, e := range sorted {
+				names = append(names, e.Name)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("sorted = %q, want %q", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceEntriesKeepsCursor(t *testing.T) {
+	m := InitialModel(DefaultOptions())
+	m.replaceEntries([]fileEntry{
+		{Name: "a.zip", Path: "a.zip", Size: 1},
+		{Name: "b.zip", Path: "b.zip", Size: 3},
+		{Name: "c.zip", Path: "c.zip", Size: 2},
+	})
+	m.setCursor(2)
+
+	m.sortMode = sortSizeDesc
+	m.applySort()
+	if name := m.entries[m.filtered[m.cursor]].Name; name != "c.zip" || m.cursor != 1 {
+		t.Errorf("cursor on %q at %d after sorting, want c.zip at 1", name, m.cursor)
+	}
+}
@@ -0,0 +1,169 @@
+package myrient_browser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const stateFileName = "state.json"
+
+// savedState is the part of the UI that survives between sessions.
+type savedState struct {
+	SkipScan        bool   `json:"skip_scan"`
+	AutoExtract     bool   `json:"auto_extract"`
+	ExtractToFolder bool   `json:"extract_to_folder"`
+	DeleteZip       bool   `json:"delete_zip"`
+	SubstringFilter bool   `json:"substring_filter"`
+	Path            string `json:"path"`
+	Cursor          int    `json:"cursor"`
+	Offset          int    `json:"offset"`
+	Filter          string `json:"filter"`
+}
+
+// DefaultStatePath returns the location of the state file under
+// $XDG_STATE_HOME, falling back to ~/.local/state.
+func DefaultStatePath() string {
+	return stateFile(stateFileName)
+}
+
+// stateFile returns the path of name in the application's state directory,
+// or "" if there is no home directory.
+func stateFile(name string) string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, appName, name)
+}
+
+// writeFileAtomic replaces the file at path with the output of write,
+// creating its directory if needed. The output goes to a temporary file in
+// the same directory first, so a failed or concurrent write never leaves a
+// partial file behind.
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	err = write(tmp)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// loadState reads the state file at path. It returns nil if there is no
+// saved state.
+func loadState(path string) (*savedState, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var st savedState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("failed to parse state %s: %w", path, err)
+	}
+	return &st, nil
+}
+
+// writeState atomically replaces the state file at path.
+func writeState(path string, st savedState) error {
+	if path == "" {
+		return nil
+	}
+
+	return writeFileAtomic(path, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(st)
+	})
+}
+
+// saveState persists the toggles and current location.
+func (m *Model) saveState() {
+	cursor, offset := m.cursor, m.viewport.offset
+	if m.restore != nil {
+		// The saved directory hasn't loaded yet, keep its position.
+		cursor, offset = m.restore.Cursor, m.restore.Offset
+	}
+
+	err := writeState(m.opts.StatePath, savedState{
+		SkipScan:        m.skipScan,
+		AutoExtract:     m.autoExtract,
+		ExtractToFolder: m.extractToFolder,
+		DeleteZip:       m.deleteZip,
+		SubstringFilter: m.substringFilter,
+		Path:            m.currentPath,
+		Cursor:          cursor,
+		Offset:          offset,
+		Filter:          m.filterInput.Value(),
+	})
+	if err != nil {
+		m.lastError = fmt.Sprintf("failed to save state: %v", err)
+	}
+}
+
+// restoreState applies the saved toggles that weren't set by an environment
+// variable or a command line flag and, unless a start path was given,
+// arranges for the saved location to be restored once it has loaded.
+func (m *Model) restoreState() {
+	st, err := loadState(m.opts.StatePath)
+	if err != nil {
+		m.lastError = err.Error()
+		return
+	}
+	if st == nil {
+		return
+	}
+
+	toggles := []struct {
+		option string
+		dst    *bool
+		saved  bool
+	}{
+		{"skip_scan", &m.skipScan, st.SkipScan},
+		{"auto_extract", &m.autoExtract, st.AutoExtract},
+		{"extract_to_folder", &m.extractToFolder, st.ExtractToFolder},
+		{"delete_zip", &m.deleteZip, st.DeleteZip},
+	}
+	for _, t := range toggles {
+		if !m.opts.IsExplicit(t.option) {
+			*t.dst = t.saved
+		}
+	}
+	m.substringFilter = st.SubstringFilter
+
+	if m.opts.StartPath == "" {
+		m.opts.StartPath = st.Path
+		m.restore = st
+	}
+}
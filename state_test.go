@@ -0,0 +1,111 @@
+package myrient_browser
+
+import (
+	"errors"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRestoreState(t *testing.T) {
+	saved := savedState{SkipScan: true, AutoExtract: false, ExtractToFolder: true, DeleteZip: true, Path: "Saved/", Cursor: 3}
+
+	type toggles struct{ skipScan, autoExtract, extractToFolder, deleteZip bool }
+	tests := []struct {
+		name      string
+		noState   bool
+		explicit  []string
+		startPath string
+		want      toggles
+		wantStart string
+	}{
+		{
+			name:      "saved state",
+			want:      toggles{true, false, true, true},
+			wantStart: "Saved/",
+		},
+		{
+			name:      "no saved state",
+			noState:   true,
+			want:      toggles{false, true, false, false},
+			wantStart: "",
+		},
+		{
+			name:      "explicit toggles win",
+			explicit:  []string{"skip_scan", "auto_extract"},
+			want:      toggles{false, true, true, true},
+			wantStart: "Saved/",
+		},
+		{
+			name:      "every toggle explicit",
+			explicit:  []string{"skip_scan", "auto_extract", "extract_to_folder", "delete_zip"},
+			want:      toggles{false, true, false, false},
+			wantStart: "Saved/",
+		},
+		{
+			name:      "start path wins",
+			startPath: "Given/",
+			want:      toggles{true, false, true, true},
+			wantStart: "Given/",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.SkipScan, opts.AutoExtract, opts.ExtractToFolder, opts.DeleteZip = false, true, false, false
+			opts.StatePath = filepath.Join(t.TempDir(), "state.json")
+			opts.StartPath = tt.startPath
+			for _, name := range tt.explicit {
+				opts.SetExplicit(name)
+			}
+			if !tt.noState {
+				if err := writeState(opts.StatePath, saved); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			m := InitialModel(opts)
+			got := toggles{m.skipScan, m.autoExtract, m.extractToFolder, m.deleteZip}
+			if got != tt.want {
+				t.Errorf("toggles = %+v, want %+v", got, tt.want)
+			}
+			if m.opts.StartPath != tt.wantStart {
+				t.Errorf("start path = %q, want %q", m.opts.StartPath, tt.wantStart)
+			}
+			if restored := m.restore != nil; restored != (tt.wantStart == "Saved/") {
+				t.Errorf("restoring the saved view = %v, want %v", restored, !restored)
+			}
+		})
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sub", "file.json")
+
+	write := func(content string, err error) func(w io.Writer) error {
+		return func(w io.Writer) error {
+			if _, werr := io.WriteString(w, content); werr != nil {
+				return werr
+			}
+			return err
+		}
+	}
+
+	if err := writeFileAtomic(path, write("first", nil)); err != nil {
+		t.Fatalf("writeFileAtomic failed: %v", err)
+	}
+	failed := errors.New("encoding failed")
+	if err := writeFileAtomic(path, write("partial", failed)); !errors.Is(err, failed) {
+		t.Fatalf("writeFileAtomic error = %v, want %v", err, failed)
+	}
+
+	if got := readFiles(t, dir); !maps.Equal(got, map[string]string{"sub/file.json": "first"}) {
+		t.Errorf("files = %v, want only the first write", got)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o644 {
+		t.Errorf("stat = %v, %v, want mode 0644", info, err)
+	}
+}
@@ -0,0 +1,213 @@
+package myrient_browser
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SyncOptions controls how a remote directory tree is mirrored locally.
+type SyncOptions struct {
+	// Delete removes local files that no longer exist upstream.
+	Delete bool
+	// DryRun returns the plan in Summary.Plan without downloading or
+	// deleting anything.
+	DryRun bool
+	// Progress receives line-based progress output. It may be nil.
+	Progress io.Writer
+	// Interval is how often an aggregate progress line is written.
+	Interval time.Duration
+}
+
+// syncPlan lists the work needed to bring a local mirror up to date.
+type syncPlan struct {
+	download []fileInfo
+	changed  map[string]bool
+	remove   []string
+}
+
+// Sync mirrors the remote directory tree at remote into localDir. Files that
+// are missing locally or whose size differs from the remote copy are
+// downloaded; archives are never extracted so the mirror stays comparable.
+func Sync(ctx context.Context, opts Options, remote, localDir string, so SyncOptions) (Summary, error) {
+	var summary Summary
+	logf := progressLogger(so.Progress)
+	start := time.Now()
+
+	opts.AutoExtract = false
+	basePath, err := ResolvePath(opts.BaseURL, remote)
+	if err != nil {
+		return summary, err
+	}
+
+	logf("listing %s", decodePath(basePath))
+	var remoteFiles []fileInfo
+	err = walkDirectory(opts, basePath, -1, func(dir string, entry fileEntry) error {
+		if strings.HasSuffix(entry.Path, "/") {
+			return nil
+		}
+		rel, ok := strings.CutPrefix(dir+entry.Path, basePath)
+		relPath := decodePath(rel)
+		// A listing must not be able to write or delete outside localDir.
+		if !ok || !filepath.IsLocal(filepath.FromSlash(relPath)) {
+			return fmt.Errorf("remote path %q is outside %s", decodePath(dir+entry.Path), decodePath(basePath))
+		}
+		remoteFiles = append(remoteFiles, fileInfo{
+			url:       opts.BaseURL + dir + entry.Path,
+			filename:  relPath,
+			path:      filepath.Join(localDir, filepath.FromSlash(relPath)),
+			resumable: true,
+		})
+		return nil
+	})
+	if err != nil {
+		return summary, err
+	}
+	// An empty listing is more likely a broken mirror than an empty
+	// directory, and pruning against it would delete the whole copy.
+	if so.Delete && len(remoteFiles) == 0 {
+		return summary, fmt.Errorf("remote listing of %s is empty, refusing to delete local files", decodePath(basePath))
+	}
+
+	stats := &downloadStats{total: int32(len(remoteFiles))}
+	logf("checking %d remote files", len(remoteFiles))
+	headFiles(ctx, remoteFiles, stats, opts.Workers)
+	if err := ctx.Err(); err != nil {
+		return summary, err
+	}
+
+	plan, err := planSync(remoteFiles, localDir, so.Delete)
+	if err != nil {
+		return summary, err
+	}
+
+	logf("plan: %d to download, %d to delete, %d up to date",
+		len(plan.download), len(plan.remove), len(remoteFiles)-len(plan.download))
+
+	if so.DryRun {
+		summary.Plan = plan.changes()
+		summary.Skipped = len(remoteFiles) - len(plan.download)
+		summary.Duration = time.Since(start)
+		return summary, nil
+	}
+
+	for _, c := range plan.changes() {
+		if c.Action == "delete" {
+			logf("delete %s", c.Path)
+		} else {
+			logf("%s %s (%.2f MB)", c.Action, c.Path, float64(c.Size)/1024/1024)
+		}
+	}
+
+	for _, f := range plan.download {
+		// A changed file must not be resumed from a stale partial download.
+		if plan.changed[f.path] {
+			_ = os.Remove(f.path + ".part")
+		}
+	}
+
+	if len(plan.download) > 0 {
+		stats = &downloadStats{total: int32(len(plan.download))}
+		for _, f := range plan.download {
+			stats.bytesTotal += f.size
+		}
+		if err := downloadWithProgress(ctx, plan.download, stats, opts, &summary, logf, so.Interval); err != nil {
+			return summary, err
+		}
+	}
+	summary.Skipped += len(remoteFiles) - len(plan.download)
+
+	if ctx.Err() == nil {
+		for _, p := range plan.remove {
+			if err := os.Remove(filepath.Join(localDir, p)); err != nil {
+				summary.Failed++
+				summary.Failures = append(summary.Failures, p)
+				logf("failed to delete %s: %v", p, err)
+				continue
+			}
+			summary.Deleted++
+			removeEmptyParents(localDir, p)
+		}
+	}
+
+	summary.Duration = time.Since(start)
+	return summary, ctx.Err()
+}
+
+// changes lists the plan in the order it is carried out.
+func (p syncPlan) changes() []Change {
+	changes := []Change{}
+	for _, f := range p.download {
+		action := "new"
+		if p.changed[f.path] {
+			action = "changed"
+		}
+		changes = append(changes, Change{Action: action, Path: f.filename, Size: f.size})
+	}
+	for _, rel := range p.remove {
+		changes = append(changes, Change{Action: "delete", Path: filepath.ToSlash(rel)})
+	}
+	return changes
+}
+
+// planSync compares the scanned remote files with localDir.
+func planSync(remoteFiles []fileInfo, localDir string, prune bool) (syncPlan, error) {
+	plan := syncPlan{changed: map[string]bool{}}
+	wanted := map[string]bool{}
+
+	for _, f := range remoteFiles {
+		wanted[f.path] = true
+
+		stat, err := os.Stat(f.path)
+		switch {
+		case err != nil:
+			plan.download = append(plan.download, f)
+		case f.size > 0 && stat.Size() != f.size:
+			plan.changed[f.path] = true
+			plan.download = append(plan.download, f)
+		}
+	}
+
+	if !prune {
+		return plan, nil
+	}
+
+	err := filepath.WalkDir(localDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == localDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || wanted[path] || wanted[strings.TrimSuffix(path, ".part")] {
+			return nil
+		}
+		rel, err := filepath.Rel(localDir, path)
+		if err != nil {
+			return err
+		}
+		plan.remove = append(plan.remove, rel)
+		return nil
+	})
+	sort.Strings(plan.remove)
+
+	return plan, err
+}
+
+// removeEmptyParents deletes the directories that held the file rel below
+// root, from the deepest up, as long as deleting the file left them empty.
+// root itself and other empty directories are kept.
+func removeEmptyParents(root, rel string) {
+	for dir := filepath.Dir(rel); dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+		// Remove fails on a directory that isn't empty.
+		if os.Remove(filepath.Join(root, dir)) != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package myrient_browser
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlanSync(t *testing.T) {
+	tests := []struct {
+		name     string
+		local    map[string]string
+		prune    bool
+		download []string
+		changed  []string
+		remove   []string
+	}{
+		{
+			name:     "empty mirror",
+			download: []string{"a.zip", "Sub Dir/b+c.zip"},
+		},
+		{
+			name:  "up to date",
+			local: map[string]string{"a.zip": "aaaa", "Sub Dir/b+c.zip": "bb"},
+			prune: true,
+		},
+		{
+			name:     "changed size",
+			local:    map[string]string{"a.zip": "aaaaaaaa", "Sub Dir/b+c.zip": "bb"},
+			download: []string{"a.zip"},
+			changed:  []string{"a.zip"},
+		},
+		{
+			name:     "extra files kept without delete",
+			local:    map[string]string{"a.zip": "aaaa", "stale.zip": "x", "Sub Dir/old.zip": "x"},
+			download: []string{"Sub Dir/b+c.zip"},
+		},
+		{
+			name:     "extra files removed with delete",
+			local:    map[string]string{"a.zip": "aaaa", "stale.zip": "x", "Sub Dir/old.zip": "x"},
+			prune:    true,
+			download: []string{"Sub Dir/b+c.zip"},
+			remove:   []string{"Sub Dir/old.zip", "stale.zip"},
+		},
+		{
+			name:     "partial downloads kept with delete",
+			local:    map[string]string{"a.zip": "aaaa", "Sub Dir/b+c.zip.part": "b", "a.zip.part.part": "x"},
+			prune:    true,
+			download: []string{"Sub Dir/b+c.zip"},
+			remove:   []string{"a.zip.part.part"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.local)
+			remote := []fileInfo{
+				{filename: "a.zip", path: filepath.Join(dir, "a.zip"), size: 4},
+				{filename: "Sub Dir/b+c.zip", path: filepath.Join(dir, "Sub Dir", "b+c.zip"), size: 2},
+			}
+
+			plan, err := planSync(remote, dir, tt.prune)
+			if err != nil {
+				t.Fatalf("planSync failed: %v", err)
+			}
+
+			var download, changed []string
+			for _, f := range plan.download {
+				download = append(download, f.filename)
+				if plan.changed[f.path] {
+					changed = append(changed, f.filename)
+				}
+			}
+			if !slices.Equal(download, tt.download) {
+				t.Errorf("download = %q, want %q", download, tt.download)
+			}
+			if !slices.Equal(changed, tt.changed) {
+				t.Errorf("changed = %q, want %q", changed, tt.changed)
+			}
+			want := make([]string, len(tt.remove))
+			for i, p := range tt.remove {
+				want[i] = filepath.FromSlash(p)
+			}
+			if !slices.Equal(plan.remove, want) {
+				t.Errorf("remove = %q, want %q", plan.remove, want)
+			}
+		})
+	}
+}
+
+func TestPlanSyncMissingDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "missing")
+	remote := []fileInfo{{filename: "a.zip", path: filepath.Join(dir, "a.zip"), size: 4}}
+
+	plan, err := planSync(remote, dir, true)
+	if err != nil {
+		t.Fatalf("planSync failed: %v", err)
+	}
+	if len(plan.download) != 1 || len(plan.remove) != 0 {
+		t.Errorf("plan = %+v, want a.zip to download and nothing to remove", plan)
+	}
+}
+
+func TestSync(t *testing.T) {
+	tests := []struct {
+		name  string
+		so    SyncOptions
+		after map[string]string
+		want  Summary
+		plan  []Change
+		log   []string
+	}{
+		{
+			name:  "dry run",
+			so:    SyncOptions{Delete: true, DryRun: true},
+			after: map[string]string{"a.zip": "a", "stale.zip": "x"},
+			plan: []Change{
+				{Action: "changed", Path: "a.zip", Size: 4},
+				{Action: "new", Path: "Sub Dir/b+c.zip", Size: 2},
+				{Action: "delete", Path: "stale.zip"},
+			},
+			log: []string{"plan: 2 to download, 1 to delete, 0 up to date"},
+		},
+		{
+			name:  "without delete",
+			after: map[string]string{"a.zip": "aaaa", "Sub Dir/b+c.zip": "bb", "stale.zip": "x"},
+			want:  Summary{OK: 2, Bytes: 6},
+			log:   []string{"plan: 2 to download, 0 to delete, 0 up to date"},
+		},
+		{
+			name:  "with delete",
+			so:    SyncOptions{Delete: true},
+			after: map[string]string{"a.zip": "aaaa", "Sub Dir/b+c.zip": "bb"},
+			want:  Summary{OK: 2, Deleted: 1, Bytes: 6},
+			log:   []string{"delete stale.zip", "plan: 2 to download, 1 to delete, 0 up to date"},
+		},
+	}
+
+	srv := httptest.NewServer(mirrorHandler(map[string]string{
+		"/files/":                `[{"name":"a.zip","type":"file","size":4},{"name":"Sub Dir","type":"directory"}]`,
+		"/files/Sub Dir/":        `[{"name":"b+c.zip","type":"file","size":2}]`,
+		"/files/a.zip":           "aaaa",
+		"/files/Sub Dir/b+c.zip": "bb",
+	}))
+	defer srv.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{"a.zip": "a", "stale.zip": "x"})
+
+			opts := DefaultOptions()
+			opts.BaseURL = srv.URL + "/files/"
+			var log bytes.Buffer
+			tt.so.Progress = &log
+
+			summary, err := Sync(context.Background(), opts, "", dir, tt.so)
+			if err != nil {
+				t.Fatalf("Sync failed: %v", err)
+			}
+
+			if summary.OK != tt.want.OK || summary.Failed != 0 || summary.Deleted != tt.want.Deleted || summary.Bytes != tt.want.Bytes {
+				t.Errorf("summary = %+v, want %+v", summary, tt.want)
+			}
+			if !slices.Equal(summary.Plan, tt.plan) {
+				t.Errorf("plan = %+v, want %+v", summary.Plan, tt.plan)
+			}
+			for _, line := range tt.log {
+				if !strings.Contains(log.String(), line) {
+					t.Errorf("log doesn't contain %q:\n%s", line, log.String())
+				}
+			}
+			if got := readFiles(t, dir); !maps.Equal(got, tt.after) {
+				t.Errorf("files = %v, want %v", got, tt.after)
+			}
+		})
+	}
+}
+
+func TestSyncDeleteRemovesEmptyParents(t *testing.T) {
+	srv := httptest.NewServer(mirrorHandler(map[string]string{
+		"/files/":      `[{"name":"a.zip","type":"file","size":4}]`,
+		"/files/a.zip": "aaaa",
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.zip":              "aaaa",
+		"Old/Deep/old.zip":   "x",
+		"Mixed/old.zip":      "x",
+		"Mixed/Kept/new.txt": "x",
+	})
+	for _, d := range []string{"Mine", "Mixed/Empty"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := DefaultOptions()
+	opts.BaseURL = srv.URL + "/files/"
+	if _, err := Sync(context.Background(), opts, "", dir, SyncOptions{Delete: true}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	tests := []struct {
+		dir  string
+		kept bool
+	}{
+		{"Old/Deep", false},
+		{"Old", false},
+		{"Mixed", true},
+		{"Mixed/Empty", true},
+		{"Mine", true},
+		{".", true},
+	}
+	for _, tt := range tests {
+		_, err := os.Stat(filepath.Join(dir, tt.dir))
+		if kept := err == nil; kept != tt.kept {
+			t.Errorf("%s kept = %v, want %v", tt.dir, kept, tt.kept)
+		}
+	}
+}
+
+func TestSyncRefuses(t *testing.T) {
+	tests := []struct {
+		name  string
+		pages map[string]string
+		so    SyncOptions
+		want  string
+	}{
+		{
+			name:  "empty listing with delete",
+			pages: map[string]string{"/files/": `[]`},
+			so:    SyncOptions{Delete: true},
+			want:  "remote listing of / is empty, refusing to delete local files",
+		},
+		{
+			name: "path outside the mirror",
+			pages: map[string]string{
+				"/files/":         `[{"name":"../../evil.zip","type":"file","size":4}]`,
+				"/evil.zip":       "evil",
+				"/files/evil.zip": "evil",
+			},
+			want: `remote path "../../evil.zip" is outside /`,
+		},
+		{
+			name: "path outside the mirror with delete",
+			pages: map[string]string{
+				"/files/":     `[{"name":"Sub","type":"directory"}]`,
+				"/files/Sub/": `[{"name":"../../keep.txt","type":"file","size":4}]`,
+			},
+			so:   SyncOptions{Delete: true},
+			want: `remote path "Sub/../../keep.txt" is outside /`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(mirrorHandler(tt.pages))
+			defer srv.Close()
+
+			root := t.TempDir()
+			dir := filepath.Join(root, "mirror")
+			writeFiles(t, root, map[string]string{"keep.txt": "keep", "mirror/a.zip": "aaaa"})
+
+			opts := DefaultOptions()
+			opts.BaseURL = srv.URL + "/files/"
+			_, err := Sync(context.Background(), opts, "", dir, tt.so)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Sync error = %v, want %q", err, tt.want)
+			}
+			want := map[string]string{"keep.txt": "keep", "mirror/a.zip": "aaaa"}
+			if got := readFiles(t, root); !maps.Equal(got, want) {
+				t.Errorf("files = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// mirrorHandler serves the given pages by decoded path. Paths ending in "/"
+// are served as nginx JSON indexes, the others as files.
+func mirrorHandler(pages map[string]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/") {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		http.ServeContent(w, r, r.URL.Path, time.Time{}, strings.NewReader(page))
+	})
+}
+
+// writeFiles creates the files, keyed by slash-separated path, under dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readFiles returns the contents of the files under dir, keyed by
+// slash-separated path.
+func readFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
@@ -9,16 +9,31 @@ import (
 )
 
 const (
-	baseURL    = "https://myrient.erista.me/files/"
-	numWorkers = 10
+	defaultBaseURL    = "https://myrient.erista.me/files/"
+	defaultNumWorkers = 10
+	defaultOutputDir  = "./downloads"
 )
 
 type Model struct {
+	opts            Options
 	entries         []fileEntry
 	filtered        []int
+	matches         map[int][]int
+	marked          map[string]bool
+	local           map[string]localFile
+	missingOnly     bool
+	localChecked    int32
 	cursor          int
+	sortMode        sortMode
 	currentPath     string
-	pathStack       []string
+	history         []historyEntry
+	historyIndex    int
+	pending         *navigation
+	clickTime       time.Time
+	clickIndex      int
+	keys            keyMap
+	keyPending      string
+	showHelp        bool
 	downloading     bool
 	paused          bool
 	status          string
@@ -26,22 +41,60 @@ type Model struct {
 	startTime       time.Time
 	pausedTime      time.Duration
 	pauseStart      time.Time
-	viewport        struct{ offset, height int }
+	viewport        struct{ offset, height, width, screenHeight int }
 	filterInput     textinput.Model
 	filtering       bool
+	filterErr       string
+	substringFilter bool
+	gotoInput       textinput.Model
+	goingTo         bool
+	searchInput     textinput.Model
+	searching       bool
+	searchResults   []*indexEntry
+	searchCursor    int
+	searchOffset    int
+	jumpTo          string
 	progress        progress.Model
 	skipScan        bool
 	autoExtract     bool
 	extractToFolder bool
 	deleteZip       bool
+	jobOpts         Options
 	ctx             context.Context
 	cancel          context.CancelFunc
 	lastError       string
+	restore         *savedState
+	cache           *listingCache
+	queue           *downloadQueue
+	queueRun        *queueRun
+	queueSeen       int64
+	showQueue       bool
+	showProgress    bool
+	showDetail      bool
+	bookmarks       []Bookmark
+	showBookmarks   bool
+	bookmarkCursor  int
+	bookmarkInput   textinput.Model
+	naming          bool
+	renaming        int
+	details         map[string]*entryDetail
+	detailWanted    string
+	progressOffset  int
+	queueCursor     int
+	index           *searchIndex
+	indexer         *indexer
+	indexDirs       int
+	indexPending    int
 }
 
 type fileEntry struct {
-	Name string
-	Path string
+	Name    string
+	Path    string
+	Size    int64
+	ModTime time.Time
+	// Rounded is true when Size comes from a value such as "1.2 GiB" and
+	// is only approximate.
+	Rounded bool `json:",omitempty"`
 }
 
 type fileInfo struct {
@@ -50,32 +103,87 @@ type fileInfo struct {
 	path      string
 	size      int64
 	resumable bool
+	// extract, extractToFolder and deleteZip are the extraction settings
+	// of the job, which differ between the items of a queue download.
+	extract, extractToFolder, deleteZip bool
 }
 
 type downloadStats struct {
 	completed     int32
+	failed        int32
+	skipped       int32
 	bytesDownload int64
 	bytesTotal    int64
 	total         int32
 	scanning      bool
 	scanProgress  int32
+	scanBytes     int64
+	crawling      bool
+	dirsCrawled   int32
+	filesFound    int32
 	lastBytes     int64
 	lastTime      time.Time
 	currentSpeed  float64
 	extracting    bool
+	toExtract     int32
 	extracted     int32
 	paused        int32
+	// files tracks each download job, in job order.
+	files []fileProgress
 }
 
+// fileProgress is the state of a single download job. The workers update
+// status, bytes, size and finished; the speed sample is kept by the view.
+type fileProgress struct {
+	name     string
+	status   int32
+	bytes    int64
+	size     int64
+	finished int64
+
+	lastBytes int64
+	lastTime  time.Time
+	speed     float64
+}
+
+// States of a download job in fileProgress.status.
+const (
+	fileQueued int32 = iota
+	fileActive
+	fileDone
+	fileFailed
+	fileSkipped
+)
+
 type (
-	dirLoadedMsg        []fileEntry
-	downloadCompleteMsg struct{}
-	tickMsg             time.Time
-	scanCompleteMsg     struct {
+	dirLoadedMsg struct {
+		path    string
+		entries []fileEntry
+		// cached is set when the entries came from the listing cache,
+		// and stale when that copy should be revalidated.
+		cached *listing
+		stale  bool
+		// revalidated marks a refreshed copy of an already shown listing.
+		revalidated bool
+	}
+	downloadCompleteMsg struct {
+		stats *downloadStats
+	}
+	tickMsg         time.Time
+	scanCompleteMsg struct {
+		// stats identifies the download the scan belongs to.
+		stats      *downloadStats
 		totalBytes int64
 		files      []fileInfo
 	}
+	// dirErrorMsg reports that the directory at path failed to load.
+	dirErrorMsg struct {
+		path string
+		err  error
+	}
 	errMsg struct {
 		err error
+		// stats is set when the error ended the download it belongs to.
+		stats *downloadStats
 	}
 )
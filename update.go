@@ -1,96 +1,217 @@
 package myrient_browser
 
 import (
+	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"sync/atomic"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	m.layout()
+	return model, tea.Batch(cmd, m.requestDetail())
+}
+
+func (m *Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		m.viewport.height = msg.Height - 12
+		m.viewport.screenHeight = msg.Height
+		m.viewport.width = msg.Width
 		return m, nil
 
 	case errMsg:
 		m.lastError = msg.err.Error()
-		m.downloading = false
+		if msg.stats != nil && msg.stats == m.downloadStats {
+			m.downloading = false
+		}
 		m.status = ""
 		return m, nil
 
+	case dirErrorMsg:
+		if m.pending == nil || msg.path != m.pending.path {
+			return m, nil
+		}
+		m.pending = nil
+		return m, m.openFailed(msg.path, msg.err)
+
 	case dirLoadedMsg:
-		m.entries = msg
+		if msg.revalidated {
+			if msg.path == m.currentPath {
+				m.replaceEntries(msg.entries)
+				m.refreshLocal()
+				m.status = "Listing refreshed"
+			}
+			return m, nil
+		}
+		if !m.opened(msg.path) {
+			return m, nil
+		}
+
+		// The entries may be those of the cached listing, which is still
+		// being revalidated in the background in server order.
+		m.entries = slices.Clone(msg.entries)
+		sortEntries(m.entries, m.sortMode)
+		m.checkLocal()
+		m.clearMarks()
 		m.cursor = 0
 		m.viewport.offset = 0
 		m.filtering = false
 		m.filterInput.SetValue("")
+		if m.restore != nil {
+			m.filterInput.SetValue(m.restore.Filter)
+		}
 		m.updateFilter()
+		if m.restore != nil {
+			m.setCursor(m.restore.Cursor)
+			m.scrollTo(m.restore.Offset)
+			m.restore = nil
+		}
+		if m.jumpTo != "" {
+			for i, idx := range m.filtered {
+				if m.entries[idx].Path == m.jumpTo {
+					m.setCursor(i)
+					break
+				}
+			}
+			m.jumpTo = ""
+		}
 		m.status = ""
+		if msg.stale {
+			return m, revalidateDirectory(m.opts, m.cache, msg.cached)
+		}
+		return m, nil
+
+	case indexLoadedMsg:
+		// A crawl started before the index was loaded supersedes it.
+		if m.indexer == nil {
+			m.index = msg.index
+		}
+		return m, nil
+
+	case indexProgressMsg:
+		if msg.ix != m.indexer {
+			return m, nil
+		}
+		m.indexDirs = msg.dirs
+		m.indexPending = msg.pending
+		// Keep searching a complete index until its replacement is done.
+		if !m.index.Complete {
+			m.index = &searchIndex{Dirs: msg.dirs, Entries: msg.entries}
+			if m.searching {
+				m.updateSearch()
+			}
+		}
+		return m, m.indexer.wait()
+
+	case indexDoneMsg:
+		if msg.ix != m.indexer {
+			return m, nil
+		}
+		m.indexer = nil
+		m.index = msg.index
+		if m.searching {
+			m.updateSearch()
+		}
+		switch {
+		case msg.err != nil:
+			m.status = fmt.Sprintf("Indexing stopped: %v", msg.err)
+		case msg.index.Complete:
+			m.status = fmt.Sprintf("✓ Indexed %d entries in %d directories", len(msg.index.Entries), msg.index.Dirs)
+		default:
+			m.status = fmt.Sprintf("Indexing paused after %d directories - press %s to resume", msg.index.Dirs, keyHint(m.keys.Index))
+		}
 		return m, nil
 
 	case scanCompleteMsg:
-		if m.downloadStats != nil {
+		// A scan that was cancelled, or belongs to an earlier download,
+		// must not start downloading.
+		if !m.downloading || msg.stats != m.downloadStats {
+			return m, nil
+		}
+		m.downloadStats.scanning = false
+		m.downloadStats.bytesTotal = msg.totalBytes
+		return m, tea.Batch(startDownloadWithFiles(msg.files, m.downloadStats, m.ctx, m.jobOpts), tickCmd())
+
+	case crawlCompleteMsg:
+		if !m.downloading || msg.stats != m.downloadStats {
+			return m, nil
+		}
+		m.downloadStats.crawling = false
+		m.downloadStats.total = int32(len(msg.files))
+		if m.queueRun != nil && m.queueRun.stats == msg.stats {
+			m.queueRun.owners = msg.owners
+		}
+		if len(msg.files) == 0 {
+			m.downloading = false
+			m.status = "No files found in the selected directories"
+			return m, nil
+		}
+		if m.jobOpts.SkipScan {
 			m.downloadStats.scanning = false
-			m.downloadStats.bytesTotal = msg.totalBytes
-			return m, tea.Batch(startDownloadWithFiles(msg.files, m.downloadStats, m.ctx, m.autoExtract, m.extractToFolder, m.deleteZip), tickCmd())
+			m.status = fmt.Sprintf("Downloading %d files...", len(msg.files))
+			return m, startDownloadWithFiles(msg.files, m.downloadStats, m.ctx, m.jobOpts)
 		}
-		return m, nil
+		m.status = fmt.Sprintf("Scanning %d files...", len(msg.files))
+		return m, scanFileInfosCmd(msg.files, m.downloadStats, m.ctx, m.jobOpts)
 
 	case tickMsg:
 		if m.downloading && m.downloadStats != nil {
-			if m.paused {
-				return m, tickCmd()
-			}
-
-			if m.downloadStats.extracting {
-				extracted := atomic.LoadInt32(&m.downloadStats.extracted)
-				if extracted >= m.downloadStats.total {
-					m.downloading = false
-					elapsed := m.pausedTime + time.Since(m.startTime)
-					m.status = fmt.Sprintf("✓ Downloaded and extracted %d files in %s",
-						m.downloadStats.total, elapsed.Round(time.Second))
-					return m, nil
-				}
+			m.refreshLocalProgress()
+			if m.paused || m.downloadStats.scanning {
 				return m, tickCmd()
 			}
 
+			// The download only ends with downloadCompleteMsg, once the zip
+			// files of the jobs that ask for it have been extracted.
 			completed := atomic.LoadInt32(&m.downloadStats.completed)
-			if completed >= m.downloadStats.total {
-				if m.autoExtract {
-					m.downloadStats.extracting = true
-					return m, tickCmd()
-				}
-
-				m.downloading = false
-				elapsed := m.pausedTime + time.Since(m.startTime)
-				bytesDownload := atomic.LoadInt64(&m.downloadStats.bytesDownload)
-				avgSpeed := float64(bytesDownload) / elapsed.Seconds() / 1024 / 1024
-				m.status = fmt.Sprintf("✓ Downloaded %d files in %s (avg %.2f MB/s)",
-					m.downloadStats.total, elapsed.Round(time.Second), avgSpeed)
-				return m, nil
+			if completed >= m.downloadStats.total && atomic.LoadInt32(&m.downloadStats.toExtract) > 0 {
+				m.downloadStats.extracting = true
 			}
-
 			return m, tickCmd()
 		}
 		return m, nil
 
 	case downloadCompleteMsg:
+		// The workers of a cancelled download still report it as complete
+		// once they stop, which must neither replace the status nor go on
+		// with the queue.
+		if !m.downloading || msg.stats != m.downloadStats {
+			return m, nil
+		}
 		m.downloading = false
 		if m.downloadStats != nil {
 			elapsed := m.pausedTime + time.Since(m.startTime)
-			bytesDownload := atomic.LoadInt64(&m.downloadStats.bytesDownload)
-			avgSpeed := float64(bytesDownload) / elapsed.Seconds() / 1024 / 1024
-			m.status = fmt.Sprintf("✓ Downloaded %d files in %s (avg %.2f MB/s)",
-				m.downloadStats.total, elapsed.Round(time.Second), avgSpeed)
+			if extracted := atomic.LoadInt32(&m.downloadStats.extracted); extracted > 0 {
+				m.status = fmt.Sprintf("✓ Downloaded %d files and extracted %d in %s%s",
+					m.downloadStats.total, extracted, elapsed.Round(time.Second), failureSuffix(m.downloadStats))
+			} else {
+				bytesDownload := atomic.LoadInt64(&m.downloadStats.bytesDownload)
+				avgSpeed := float64(bytesDownload) / elapsed.Seconds() / 1024 / 1024
+				m.status = fmt.Sprintf("✓ Downloaded %d files in %s (avg %.2f MB/s)%s",
+					m.downloadStats.total, elapsed.Round(time.Second), avgSpeed, failureSuffix(m.downloadStats))
+			}
 		}
+		return m, m.downloadFinished()
+
+	case detailTickMsg:
+		return m, m.loadDetail(msg.url)
+
+	case detailLoadedMsg:
+		m.details[msg.url] = &entryDetail{info: msg.info, err: msg.err}
 		return m, nil
 
+	case tea.MouseMsg:
+		return m, m.handleMouse(msg)
+
 	case tea.KeyMsg:
 		// Handle error dismissal
 		if m.lastError != "" {
@@ -98,53 +219,44 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
-		if m.downloading {
-			// Check if we're scanning
-			if m.downloadStats != nil && m.downloadStats.scanning {
-				switch msg.String() {
-				case "ctrl+c":
-					m.cancel()
-					return m, tea.Quit
-				case "esc":
-					m.cancel()
-					m.downloading = false
-					m.status = "Scan cancelled"
-					return m, nil
-				}
-				return m, nil
-			}
+		if m.showHelp {
+			m.showHelp = false
+			return m, nil
+		}
 
-			switch msg.String() {
-			case "ctrl+c":
-				m.cancel()
-				return m, tea.Quit
-			case "p":
-				if !m.paused {
-					m.paused = true
-					atomic.StoreInt32(&m.downloadStats.paused, 1)
-					m.pauseStart = time.Now()
-					m.status = "Paused - Press [r] to resume or [Esc] to cancel"
-				}
-				return m, nil
-			case "r":
-				if m.paused {
-					m.paused = false
-					atomic.StoreInt32(&m.downloadStats.paused, 0)
-					m.pausedTime += time.Since(m.pauseStart)
-					m.startTime = time.Now()
-					m.downloadStats.lastTime = time.Time{}
-					m.status = "Resumed downloading..."
-				}
+		if m.downloading && key.Matches(msg, m.keys.ForceQuit) {
+			return m, m.quit()
+		}
+
+		if m.downloading && m.showProgress {
+			k, ok := m.resolveKey(msg)
+			if !ok {
 				return m, nil
-			case "esc":
-				if m.paused {
-					m.cancel()
-					m.downloading = false
-					m.paused = false
-					m.status = "Download cancelled"
-					return m, nil
+			}
+			switch {
+			case key.Matches(k, m.keys.Progress):
+				m.showProgress = false
+			case key.Matches(k, m.keys.Up):
+				m.progressOffset = max(m.progressOffset-1, 0)
+			case key.Matches(k, m.keys.Down):
+				m.progressOffset++
+			case key.Matches(k, m.keys.PageUp):
+				m.progressOffset = max(m.progressOffset-m.viewport.height, 0)
+			case key.Matches(k, m.keys.PageDown):
+				m.progressOffset += m.viewport.height
+			case key.Matches(k, m.keys.Home):
+				m.progressOffset = 0
+			case key.Matches(k, m.keys.Pause):
+				m.pauseDownload()
+			case key.Matches(k, m.keys.Resume):
+				m.resumeDownload()
+			case key.Matches(k, m.keys.Cancel):
+				switch {
+				case m.downloadStats.scanning:
+					m.cancelDownload("Scan cancelled")
+				case m.paused:
+					m.cancelDownload("Download cancelled")
 				}
-				return m, nil
 			}
 			return m, nil
 		}
@@ -159,6 +271,9 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "enter":
 				m.filtering = false
 				return m, nil
+			case "tab":
+				m.toggleSubstringFilter()
+				return m, nil
 			default:
 				m.filterInput, cmd = m.filterInput.Update(msg)
 				m.updateFilter()
@@ -166,48 +281,250 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
-		switch msg.String() {
-		case "ctrl+c", "q":
-			return m, tea.Quit
+		if m.goingTo {
+			switch msg.String() {
+			case "esc":
+				m.goingTo = false
+				m.gotoInput.Blur()
+				return m, nil
+			case "enter":
+				m.goingTo = false
+				m.gotoInput.Blur()
+				location := m.gotoInput.Value()
+				m.gotoInput.SetValue("")
+				return m, m.navigateTo(location)
+			default:
+				m.gotoInput, cmd = m.gotoInput.Update(msg)
+				return m, cmd
+			}
+		}
+
+		if m.searching {
+			if key.Matches(msg, m.keys.ForceQuit) {
+				return m, m.quit()
+			}
+			switch {
+			case key.Matches(msg, m.keys.SearchClose):
+				m.searching = false
+				m.searchInput.Blur()
+				return m, nil
+			case key.Matches(msg, m.keys.SearchUp):
+				m.moveSearchCursor(-1)
+				return m, nil
+			case key.Matches(msg, m.keys.SearchDown):
+				m.moveSearchCursor(1)
+				return m, nil
+			case key.Matches(msg, m.keys.SearchPageUp):
+				m.moveSearchCursor(-m.viewport.height)
+				return m, nil
+			case key.Matches(msg, m.keys.SearchPageDown):
+				m.moveSearchCursor(m.viewport.height)
+				return m, nil
+			case key.Matches(msg, m.keys.SearchOpen):
+				if m.searchCursor < len(m.searchResults) {
+					return m, m.openSearchResult(m.searchResults[m.searchCursor])
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.SearchQueue):
+				if m.searchCursor < len(m.searchResults) {
+					e := m.searchResults[m.searchCursor]
+					m.toggleQueued(e.Dir, e.fileEntry)
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.SearchDownload):
+				m.searching = false
+				m.searchInput.Blur()
+				return m, m.startQueue()
+			default:
+				m.searchInput, cmd = m.searchInput.Update(msg)
+				m.updateSearch()
+				return m, cmd
+			}
+		}
+
+		if m.naming {
+			switch msg.String() {
+			case "esc":
+				m.naming = false
+				m.bookmarkInput.Blur()
+				return m, nil
+			case "enter":
+				m.finishNaming()
+				return m, nil
+			default:
+				m.bookmarkInput, cmd = m.bookmarkInput.Update(msg)
+				return m, cmd
+			}
+		}
+
+		k, ok := m.resolveKey(msg)
+		if !ok {
+			return m, nil
+		}
+
+		if m.showBookmarks {
+			switch {
+			case key.Matches(k, m.keys.Quit, m.keys.ForceQuit):
+				return m, m.quitKey(k)
+			case key.Matches(k, m.keys.Close, m.keys.Bookmarks):
+				m.showBookmarks = false
+			case key.Matches(k, m.keys.Up):
+				m.bookmarkCursor = max(m.bookmarkCursor-1, 0)
+			case key.Matches(k, m.keys.Down):
+				m.bookmarkCursor = max(min(m.bookmarkCursor+1, len(m.bookmarks)-1), 0)
+			case key.Matches(k, m.keys.Open):
+				return m, m.openBookmark()
+			case key.Matches(k, m.keys.Rename):
+				if m.bookmarkCursor < len(m.bookmarks) {
+					return m, m.startNaming(m.bookmarkCursor)
+				}
+			case key.Matches(k, m.keys.Remove):
+				m.deleteBookmark()
+			}
+			return m, nil
+		}
+
+		if m.showQueue {
+			switch {
+			case key.Matches(k, m.keys.Quit, m.keys.ForceQuit):
+				return m, m.quitKey(k)
+			case key.Matches(k, m.keys.Close, m.keys.QueuePanel):
+				m.showQueue = false
+			case key.Matches(k, m.keys.Up):
+				m.queueCursor = max(m.queueCursor-1, 0)
+			case key.Matches(k, m.keys.Down):
+				m.queueCursor = max(min(m.queueCursor+1, len(m.queue.Items)-1), 0)
+			case key.Matches(k, m.keys.MoveItemUp):
+				m.moveQueueItem(-1)
+			case key.Matches(k, m.keys.MoveItemDown):
+				m.moveQueueItem(1)
+			case key.Matches(k, m.keys.Remove):
+				m.removeQueueItem()
+			case key.Matches(k, m.keys.ClearFinished):
+				m.clearFinished()
+			case key.Matches(k, m.keys.Open):
+				return m, m.startQueue()
+			case key.Matches(k, m.keys.Progress, m.keys.Pause, m.keys.Resume):
+				m.downloadKey(k)
+			}
+			return m, nil
+		}
+
+		switch {
+		case key.Matches(k, m.keys.Quit, m.keys.ForceQuit):
+			return m, m.quitKey(k)
+
+		case key.Matches(k, m.keys.Progress, m.keys.Pause, m.keys.Resume):
+			m.downloadKey(k)
 
-		case "s":
+		case key.Matches(k, m.keys.Queue):
+			m.enqueueSelection()
+
+		case key.Matches(k, m.keys.Help):
+			m.showHelp = true
+
+		case key.Matches(k, m.keys.MissingOnly):
+			m.toggleMissingOnly()
+
+		case key.Matches(k, m.keys.Bookmark):
+			return m, m.startNaming(-1)
+
+		case key.Matches(k, m.keys.Bookmarks):
+			m.showBookmarks = true
+			m.status = ""
+			m.bookmarkCursor = max(min(m.bookmarkCursor, len(m.bookmarks)-1), 0)
+
+		case key.Matches(k, m.keys.Details):
+			m.showDetail = !m.showDetail
+
+		case key.Matches(k, m.keys.QueuePanel):
+			m.settleQueue()
+			m.showQueue = true
+			m.queueCursor = max(min(m.queueCursor, len(m.queue.Items)-1), 0)
+
+		case key.Matches(k, m.keys.GoTo):
+			m.goingTo = true
+			m.gotoInput.Focus()
+			return m, textinput.Blink
+
+		case key.Matches(k, m.keys.Search):
+			m.searching = true
+			m.searchInput.Focus()
+			m.updateSearch()
+			if m.index == nil {
+				m.status = "No search index yet - press " + keyHint(m.keys.Index) + " to build one"
+			}
+			return m, textinput.Blink
+
+		case key.Matches(k, m.keys.Index):
+			if m.indexer != nil {
+				if !m.indexer.stopping {
+					m.pauseIndexer()
+				}
+				m.status = "Pausing the index..."
+				return m, nil
+			}
+			if m.index == nil {
+				m.index = &searchIndex{}
+			}
+			m.indexer = startIndexer(m.opts, m.cache, m.index)
+			m.status = "Indexing the mirror in the background..."
+			return m, m.indexer.wait()
+
+		case key.Matches(k, m.keys.PreScan):
 			m.skipScan = !m.skipScan
 			if m.skipScan {
 				m.status = "Scan disabled - downloads will start immediately"
 			} else {
 				m.status = "Scan enabled - will check file sizes before downloading"
 			}
+			m.saveState()
 
-		case "x":
+		case key.Matches(k, m.keys.Extract):
 			m.autoExtract = !m.autoExtract
 			if m.autoExtract {
 				m.status = "Auto-extract enabled - will unzip files after download"
 			} else {
 				m.status = "Auto-extract disabled"
 			}
+			m.saveState()
 
-		case "f":
+		case key.Matches(k, m.keys.Folder):
 			m.extractToFolder = !m.extractToFolder
 			if m.extractToFolder {
 				m.status = "Extract to folder: ON - creates folder per zip file"
 			} else {
 				m.status = "Extract to folder: OFF - extracts directly to current directory"
 			}
+			m.saveState()
 
-		case "z":
+		case key.Matches(k, m.keys.DeleteZip):
 			m.deleteZip = !m.deleteZip
 			if m.deleteZip {
 				m.status = "Delete zip: ON - will delete zip files after extraction"
 			} else {
 				m.status = "Delete zip: OFF - keeps zip files after extraction"
 			}
+			m.saveState()
+
+		case key.Matches(k, m.keys.FilterMode):
+			m.toggleSubstringFilter()
 
-		case "/":
+		case key.Matches(k, m.keys.Refresh):
+			m.status = "Refreshing..."
+			return m, refreshDirectory(m.opts, m.currentPath, m.cache)
+
+		case key.Matches(k, m.keys.Sort):
+			m.sortMode = (m.sortMode + 1) % numSortModes
+			m.applySort()
+			m.status = fmt.Sprintf("Sorted by %s", m.sortMode)
+
+		case key.Matches(k, m.keys.Filter):
 			m.filtering = true
 			m.filterInput.Focus()
 			return m, textinput.Blink
 
-		case "up":
+		case key.Matches(k, m.keys.Up):
 			if m.cursor > 0 {
 				m.cursor--
 				if m.cursor < m.viewport.offset {
@@ -215,7 +532,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 
-		case "down":
+		case key.Matches(k, m.keys.Down):
 			if m.cursor < len(m.filtered)-1 {
 				m.cursor++
 				if m.cursor >= m.viewport.offset+m.viewport.height {
@@ -223,14 +540,14 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 
-		case "pgup":
+		case key.Matches(k, m.keys.PageUp):
 			m.cursor -= m.viewport.height
 			if m.cursor < 0 {
 				m.cursor = 0
 			}
 			m.viewport.offset = m.cursor
 
-		case "pgdown":
+		case key.Matches(k, m.keys.PageDown):
 			m.cursor += m.viewport.height
 			if m.cursor >= len(m.filtered) {
 				m.cursor = len(m.filtered) - 1
@@ -239,17 +556,40 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.viewport.offset = m.cursor - m.viewport.height + 1
 			}
 
-		case "home":
+		case key.Matches(k, m.keys.Home):
 			m.cursor = 0
 			m.viewport.offset = 0
 
-		case "end":
+		case key.Matches(k, m.keys.End):
 			m.cursor = len(m.filtered) - 1
 			if m.cursor >= m.viewport.height {
 				m.viewport.offset = m.cursor - m.viewport.height + 1
 			}
 
-		case "d":
+		case key.Matches(k, m.keys.Mark):
+			m.toggleMark()
+
+		case key.Matches(k, m.keys.MarkAll):
+			m.markAll()
+			m.status = m.markedSummary()
+
+		case key.Matches(k, m.keys.InvertMarks):
+			m.invertMarks()
+			m.status = m.markedSummary()
+
+		case key.Matches(k, m.keys.ClearMarks):
+			m.clearMarks()
+			m.status = "Marks cleared"
+
+		case key.Matches(k, m.keys.DownloadMarked):
+			return m, m.downloadMarked()
+
+		case key.Matches(k, m.keys.Download):
+			if m.filterErr != "" {
+				m.status = "Fix the filter before downloading: " + m.filterErr
+				return m, nil
+			}
+
 			var files []fileEntry
 			for _, idx := range m.filtered {
 				entry := m.entries[idx]
@@ -258,85 +598,246 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 
+			// Profile filters only apply to bulk downloads.
+			_, files = m.opts.withProfile(m.currentPath, files)
+
 			if len(files) == 0 {
 				m.status = "No files to download in current view"
 				return m, nil
 			}
 
-			m.downloading = true
-			m.paused = false
-			m.pausedTime = 0
-			m.downloadStats = &downloadStats{
-				total:    int32(len(files)),
-				scanning: !m.skipScan,
-			}
-			m.startTime = time.Now()
+			return m, m.startDownload(m.currentPath, files, fmt.Sprintf("%d files", len(files)))
 
-			if m.skipScan {
-				m.status = fmt.Sprintf("Starting download of %d files...", len(files))
-				return m, tea.Batch(downloadAllFiles(m.currentPath, files, m.downloadStats, m.ctx, m.autoExtract, m.extractToFolder, m.deleteZip), tickCmd())
-			} else {
-				m.status = fmt.Sprintf("Scanning %d files...", len(files))
-				return m, tea.Batch(scanAndDownload(m.currentPath, files, m.downloadStats, m.ctx), tickCmd())
+		case key.Matches(k, m.keys.DownloadRecursive):
+			if m.filterErr != "" {
+				m.status = "Fix the filter before downloading: " + m.filterErr
+				return m, nil
 			}
 
-		case "right", "enter":
-			if m.cursor >= len(m.filtered) {
+			files, dirs := m.recursiveDownloadEntries()
+
+			if len(files) == 0 && len(dirs) == 0 {
+				m.status = "Nothing to download in current view"
 				return m, nil
 			}
 
-			entry := m.entries[m.filtered[m.cursor]]
-			isDir := strings.HasSuffix(entry.Path, "/")
-
-			if isDir {
-				if entry.Path == "../" {
-					if len(m.pathStack) > 0 {
-						m.currentPath = m.pathStack[len(m.pathStack)-1]
-						m.pathStack = m.pathStack[:len(m.pathStack)-1]
-					} else {
-						m.currentPath = ""
-					}
-				} else {
-					m.pathStack = append(m.pathStack, m.currentPath)
-					if m.currentPath == "" {
-						m.currentPath = entry.Path
-					} else {
-						m.currentPath = m.currentPath + entry.Path
-					}
-				}
-				return m, loadDirectory(m.currentPath)
-			} else {
-				m.downloading = true
-				m.paused = false
-				m.pausedTime = 0
-				m.downloadStats = &downloadStats{
-					total:    1,
-					scanning: !m.skipScan,
-				}
-				m.startTime = time.Now()
-				m.status = fmt.Sprintf("Downloading %s...", entry.Name)
-				files := []fileEntry{entry}
-
-				if m.skipScan {
-					return m, tea.Batch(downloadAllFiles(m.currentPath, files, m.downloadStats, m.ctx, m.autoExtract, m.extractToFolder, m.deleteZip), tickCmd())
-				} else {
-					return m, tea.Batch(scanAndDownload(m.currentPath, files, m.downloadStats, m.ctx), tickCmd())
-				}
-			}
+			return m, m.startRecursiveDownload(files, dirs)
 
-		case "left":
-			if len(m.pathStack) > 0 {
-				m.currentPath = m.pathStack[len(m.pathStack)-1]
-				m.pathStack = m.pathStack[:len(m.pathStack)-1]
-				m.status = ""
-				return m, loadDirectory(m.currentPath)
-			} else if m.currentPath != "" {
-				m.currentPath = ""
-				m.status = ""
-				return m, loadDirectory("")
-			}
+		case key.Matches(k, m.keys.Open):
+			return m, m.openEntry()
+
+		case key.Matches(k, m.keys.Back):
+			return m, m.back()
+
+		case key.Matches(k, m.keys.Forward):
+			return m, m.forward()
 		}
 	}
 
 	return m, nil
 }
+
+// quit stops the download and the indexer, saves the session and exits.
+func (m *Model) quit() tea.Cmd {
+	m.cancel()
+	m.abandonIndexer()
+	m.saveState()
+	m.saveQueue()
+	return tea.Quit
+}
+
+// quitKey quits for a quit key. While a download is running only ForceQuit
+// quits, so that a stray key can't kill it.
+func (m *Model) quitKey(k keyPress) tea.Cmd {
+	if m.downloading && !key.Matches(k, m.keys.ForceQuit) {
+		m.status = "A download is running - press " + keyHint(m.keys.ForceQuit) + " to quit anyway"
+		return nil
+	}
+	return m.quit()
+}
+
+// toggleSubstringFilter switches the filter between fuzzy and plain substring
+// matching.
+func (m *Model) toggleSubstringFilter() {
+	m.substringFilter = !m.substringFilter
+	m.updateFilter()
+	if m.substringFilter {
+		m.status = "Filter: substring - matches names containing the exact text"
+	} else {
+		m.status = "Filter: fuzzy - matches words in any order, best matches first"
+	}
+	m.saveState()
+}
+
+// failureSuffix returns a note about failed downloads for the status line.
+func failureSuffix(stats *downloadStats) string {
+	if failed := atomic.LoadInt32(&stats.failed); failed > 0 {
+		return fmt.Sprintf(" - %d failed", failed)
+	}
+	return ""
+}
+
+// setCursor moves the cursor to i, clamped to the filtered entries, and
+// scrolls the viewport so that it is visible.
+func (m *Model) setCursor(i int) {
+	if i >= len(m.filtered) {
+		i = len(m.filtered) - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	m.cursor = i
+	if m.cursor < m.viewport.offset {
+		m.viewport.offset = m.cursor
+	} else if m.viewport.height > 0 && m.cursor >= m.viewport.offset+m.viewport.height {
+		m.viewport.offset = m.cursor - m.viewport.height + 1
+	}
+}
+
+// downloadFinished rechecks the local state of the listing after a download
+// and goes on with anything queued while it ran.
+func (m *Model) downloadFinished() tea.Cmd {
+	m.refreshLocal()
+	return m.finishQueue()
+}
+
+// downloadBusy reports whether a download is already running, in which case
+// another can't be started and the status line says so.
+func (m *Model) downloadBusy() bool {
+	if m.downloading {
+		m.status = "A download is already running - press " + keyHint(m.keys.Queue) + " to queue more"
+	}
+	return m.downloading
+}
+
+// downloadKey handles the keys that control a download running in the
+// background: showing the progress screen, pausing and resuming.
+func (m *Model) downloadKey(k keyPress) {
+	if !m.downloading {
+		return
+	}
+	switch {
+	case key.Matches(k, m.keys.Progress):
+		m.showProgress = true
+	case key.Matches(k, m.keys.Pause):
+		m.pauseDownload()
+	case key.Matches(k, m.keys.Resume):
+		m.resumeDownload()
+	}
+}
+
+func (m *Model) pauseDownload() {
+	if m.paused || m.downloadStats.scanning {
+		return
+	}
+	m.paused = true
+	atomic.StoreInt32(&m.downloadStats.paused, 1)
+	m.pauseStart = time.Now()
+	m.status = "Paused - Press " + keyHint(m.keys.Resume) + " to resume"
+}
+
+func (m *Model) resumeDownload() {
+	if !m.paused {
+		return
+	}
+	m.paused = false
+	atomic.StoreInt32(&m.downloadStats.paused, 0)
+	m.pausedTime += time.Since(m.pauseStart)
+	m.startTime = time.Now()
+	m.downloadStats.lastTime = time.Time{}
+	m.status = "Resumed downloading..."
+}
+
+// cancelDownload stops the running download or scan.
+func (m *Model) cancelDownload(status string) {
+	m.cancel()
+	m.downloading = false
+	m.paused = false
+	m.status = status
+}
+
+// openEntry opens the directory under the cursor or downloads the file.
+func (m *Model) openEntry() tea.Cmd {
+	entry, ok := m.selectedEntry()
+	if !ok {
+		return nil
+	}
+
+	if strings.HasSuffix(entry.Path, "/") {
+		if entry.Path == "../" {
+			return m.goUp()
+		}
+		return m.visit(m.currentPath + entry.Path)
+	}
+	return m.startDownload(m.currentPath, []fileEntry{entry}, entry.Name)
+}
+
+// scrollTo moves the viewport to offset as far as it can while keeping the
+// cursor in view.
+func (m *Model) scrollTo(offset int) {
+	offset = min(offset, m.cursor)
+	if m.viewport.height > 0 {
+		offset = max(offset, m.cursor-m.viewport.height+1)
+	}
+	m.viewport.offset = max(offset, 0)
+}
+
+// beginDownload switches to the download screen for a new run of jobs with
+// opts, tracked by stats.
+func (m *Model) beginDownload(opts Options, stats *downloadStats) {
+	// Cancelling a previous download or scan cancels the shared context.
+	if m.ctx.Err() != nil {
+		m.ctx, m.cancel = context.WithCancel(context.Background())
+	}
+	m.settleQueue()
+	m.queueSeen = m.queue.NextID
+
+	m.jobOpts = opts
+	m.downloading = true
+	m.paused = false
+	m.pausedTime = 0
+	m.progressOffset = 0
+	m.downloadStats = stats
+	m.startTime = time.Now()
+}
+
+// startDownloadInfos begins downloading jobs that may come from different
+// remote directories, using the current toggles.
+func (m *Model) startDownloadInfos(files []fileInfo, what string) tea.Cmd {
+	if m.downloadBusy() {
+		return nil
+	}
+	opts := m.downloadOptions()
+	m.beginDownload(opts, &downloadStats{
+		total:    int32(len(files)),
+		scanning: !opts.SkipScan,
+	})
+
+	if m.jobOpts.SkipScan {
+		m.status = fmt.Sprintf("Downloading %s...", what)
+		return tea.Batch(startDownloadWithFiles(files, m.downloadStats, m.ctx, m.jobOpts), tickCmd())
+	}
+	m.status = fmt.Sprintf("Scanning %s...", what)
+	return tea.Batch(scanFileInfosCmd(files, m.downloadStats, m.ctx, m.jobOpts), tickCmd())
+}
+
+// startDownload begins downloading files from the remote directory basePath
+// with the options in effect for it. what describes the files in the status
+// line.
+func (m *Model) startDownload(basePath string, files []fileEntry, what string) tea.Cmd {
+	if m.downloadBusy() {
+		return nil
+	}
+	opts := m.optionsFor(basePath)
+	m.beginDownload(opts, &downloadStats{
+		total:    int32(len(files)),
+		scanning: !opts.SkipScan,
+	})
+
+	if m.jobOpts.SkipScan {
+		m.status = fmt.Sprintf("Downloading %s...", what)
+		return tea.Batch(downloadAllFiles(basePath, files, m.downloadStats, m.ctx, m.jobOpts), tickCmd())
+	}
+	m.status = fmt.Sprintf("Scanning %s...", what)
+	return tea.Batch(scanAndDownload(basePath, files, m.downloadStats, m.ctx, m.jobOpts), tickCmd())
+}
@@ -0,0 +1,192 @@
+package myrient_browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newTestModel returns a model browsing a test server that serves pages as
+// mirrorHandler does, with the root directory loaded.
+func newTestModel(t *testing.T, pages map[string]string) *Model {
+	t.Helper()
+	srv := httptest.NewServer(mirrorHandler(pages))
+	t.Cleanup(srv.Close)
+
+	opts := DefaultOptions()
+	opts.BaseURL = srv.URL + "/files/"
+	opts.OutputDir = t.TempDir()
+	m := InitialModel(opts)
+	runCmd(m, m.Init())
+	return m
+}
+
+// runCmd runs cmd and feeds the messages it produces back into m until there
+// are none left. Ticks are dropped so that a running download doesn't loop.
+func runCmd(m *Model, cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	switch msg := cmd().(type) {
+	case nil, tickMsg:
+	case tea.BatchMsg:
+		for _, c := range msg {
+			runCmd(m, c)
+		}
+	default:
+		_, next := m.Update(msg)
+		runCmd(m, next)
+	}
+}
+
+func TestScanCompleteIgnoredAfterCancel(t *testing.T) {
+	files := []fileInfo{{url: "http://example.org/files/a.zip", filename: "a.zip", path: "/tmp/a.zip"}}
+
+	tests := []struct {
+		name  string
+		setup func(m *Model)
+	}{
+		{"cancelled during the scan", func(m *Model) {
+			m.showProgress = true
+			m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		}},
+		{"superseded by another download", func(m *Model) {
+			m.cancel()
+			m.downloading = false
+			m.beginDownload(m.downloadOptions(), &downloadStats{total: 1, scanning: true})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := InitialModel(DefaultOptions())
+			stats := &downloadStats{total: 1, scanning: true}
+			m.beginDownload(m.downloadOptions(), stats)
+			tt.setup(m)
+			current, downloading := m.downloadStats, m.downloading
+
+			_, cmd := m.Update(scanCompleteMsg{stats: stats, totalBytes: 4, files: files})
+			if cmd != nil {
+				t.Error("a stale scan result started a download")
+			}
+			if m.downloadStats != current || m.downloading != downloading {
+				t.Error("a stale scan result changed the running download")
+			}
+			if current != stats && (!current.scanning || current.bytesTotal != 0) {
+				t.Error("a stale scan result was applied to the new download")
+			}
+		})
+	}
+}
+
+func TestScanCompleteStartsDownload(t *testing.T) {
+	m := InitialModel(DefaultOptions())
+	stats := &downloadStats{total: 1, scanning: true}
+	m.beginDownload(m.downloadOptions(), stats)
+
+	_, cmd := m.Update(scanCompleteMsg{stats: stats, totalBytes: 4})
+	if cmd == nil {
+		t.Fatal("the scan result didn't start the download")
+	}
+	if stats.scanning || stats.bytesTotal != 4 {
+		t.Errorf("stats = scanning %v, %d bytes, want the scan applied", stats.scanning, stats.bytesTotal)
+	}
+}
+
+func TestCancelPausedDownload(t *testing.T) {
+	m := newTestModel(t, map[string]string{
+		"/files/":      `[{"name":"a.zip","type":"file","size":1}]`,
+		"/files/a.zip": "a",
+	})
+	stats := &downloadStats{total: 1}
+	m.beginDownload(m.downloadOptions(), stats)
+	m.toggleQueued("", m.entries[0])
+	m.showProgress = true
+	m.pauseDownload()
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	// The workers of the cancelled run still report it as complete.
+	_, cmd := m.Update(downloadCompleteMsg{stats: stats})
+	runCmd(m, cmd)
+	if m.downloading {
+		t.Error("the item queued during the cancelled download was started")
+	}
+	if m.status != "Download cancelled" {
+		t.Errorf("status = %q, want %q", m.status, "Download cancelled")
+	}
+	if it := m.queue.Items[0]; it.Status != queueStatusQueued {
+		t.Errorf("queued item is %q, want it still queued", it.Status)
+	}
+}
+
+func TestPauseIndexerDoesNotBlock(t *testing.T) {
+	requested, release := make(chan struct{}), make(chan struct{})
+	pages := mirrorHandler(map[string]string{
+		"/files/":      `[{"name":"Slow","type":"directory"}]`,
+		"/files/Slow/": `[{"name":"Deeper","type":"directory"}]`,
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/files/Slow/" {
+			close(requested)
+			<-release
+		}
+		pages.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	defer func() {
+		select {
+		case <-release:
+		default:
+			close(release)
+		}
+	}()
+
+	opts := DefaultOptions()
+	opts.BaseURL = srv.URL + "/files/"
+	opts.IndexPath = filepath.Join(t.TempDir(), "index.json.gz")
+	opts.CrawlDelay = 0
+	m := InitialModel(opts)
+	runCmd(m, m.Init())
+
+	index := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("I")}
+	m.Update(index)
+	ix := m.indexer
+	if ix == nil {
+		t.Fatal("the index key didn't start the indexer")
+	}
+	<-requested
+
+	for range 2 {
+		done := make(chan struct{})
+		go func() {
+			m.Update(index)
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("pausing the index blocked on the running crawl")
+		}
+		if m.indexer != ix || !ix.stopping {
+			t.Fatal("the indexer was dropped before it finished")
+		}
+		if m.status != "Pausing the index..." {
+			t.Errorf("status = %q, want %q", m.status, "Pausing the index...")
+		}
+	}
+
+	close(release)
+	runCmd(m, ix.wait())
+	if m.indexer != nil {
+		t.Fatal("the indexer is still running after it finished")
+	}
+	if m.index == nil || m.index.Complete || m.index.Dirs != 2 {
+		t.Fatalf("index = %+v, want the 2 directories crawled before the pause", m.index)
+	}
+	if want := "Indexing paused after 2 directories - press [I] to resume"; m.status != want {
+		t.Errorf("status = %q, want %q", m.status, want)
+	}
+}
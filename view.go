@@ -1,13 +1,15 @@
 package myrient_browser
 
 import (
+	"cmp"
 	"fmt"
-	"net/url"
+	"slices"
 	"strings"
 	"sync/atomic"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
 )
 
 func (m *Model) View() string {
@@ -18,26 +20,38 @@ func (m *Model) View() string {
 			"Press any key to continue..."
 	}
 
-	if m.downloading && m.downloadStats != nil {
+	if m.downloading && m.downloadStats != nil && m.showProgress {
 		s := strings.Builder{}
 
 		if m.downloadStats.extracting {
 			extracted := atomic.LoadInt32(&m.downloadStats.extracted)
-			total := m.downloadStats.total
+			total := max(atomic.LoadInt32(&m.downloadStats.toExtract), 1)
 			s.WriteString(fmt.Sprintf("\nExtracting files: %d/%d\n\n", extracted, total))
 			percent := float64(extracted) / float64(total)
 			s.WriteString(m.progress.ViewAs(percent) + "\n\n")
-			s.WriteString("Almost done...\n")
+			s.WriteString("Almost done...\n\n" + hint("Browse", m.keys.Progress) + "\n")
+			return s.String()
+		}
+
+		if m.downloadStats.crawling {
+			dirs := atomic.LoadInt32(&m.downloadStats.dirsCrawled)
+			found := atomic.LoadInt32(&m.downloadStats.filesFound)
+			s.WriteString(fmt.Sprintf("\nCrawling directories: %d directories, %d files found\n\n", dirs, found))
+			s.WriteString(hints(hint("Cancel", m.keys.Cancel), hint("Browse", m.keys.Progress), hint("Quit", m.keys.ForceQuit)) + "\n")
 			return s.String()
 		}
 
 		if m.downloadStats.scanning {
 			scanned := atomic.LoadInt32(&m.downloadStats.scanProgress)
 			total := m.downloadStats.total
-			s.WriteString(fmt.Sprintf("\nScanning files: %d/%d\n\n", scanned, total))
+			s.WriteString(fmt.Sprintf("\nScanning files: %d/%d", scanned, total))
+			if scanBytes := atomic.LoadInt64(&m.downloadStats.scanBytes); scanBytes > 0 {
+				s.WriteString(fmt.Sprintf(" (%s)", formatSize(scanBytes)))
+			}
+			s.WriteString("\n\n")
 			percent := float64(scanned) / float64(total)
 			s.WriteString(m.progress.ViewAs(percent) + "\n\n")
-			s.WriteString("[Esc] Cancel scan [Ctrl+C] Quit\n")
+			s.WriteString(hints(hint("Cancel scan", m.keys.Cancel), hint("Browse", m.keys.Progress), hint("Quit", m.keys.ForceQuit)) + "\n")
 			return s.String()
 		}
 
@@ -53,50 +67,8 @@ func (m *Model) View() string {
 			percent = float64(completed) / float64(total)
 		}
 
-		var elapsed time.Duration
-		if m.paused {
-			elapsed = m.pausedTime + time.Since(m.pauseStart)
-		} else {
-			elapsed = m.pausedTime + time.Since(m.startTime)
-		}
-
-		speed := 0.0
-		eta := "calculating..."
-
-		if !m.paused {
-			now := time.Now()
-			if m.downloadStats.lastTime.IsZero() {
-				m.downloadStats.lastTime = m.startTime
-				m.downloadStats.lastBytes = 0
-			}
-
-			timeDiff := now.Sub(m.downloadStats.lastTime).Seconds()
-			if timeDiff >= 0.1 {
-				bytesDiff := bytesDownload - m.downloadStats.lastBytes
-				instantSpeed := float64(bytesDiff) / timeDiff / 1024 / 1024
-
-				if m.downloadStats.currentSpeed == 0 {
-					m.downloadStats.currentSpeed = instantSpeed
-				} else {
-					m.downloadStats.currentSpeed = 0.7*m.downloadStats.currentSpeed + 0.3*instantSpeed
-				}
-
-				m.downloadStats.lastTime = now
-				m.downloadStats.lastBytes = bytesDownload
-			}
-
-			speed = m.downloadStats.currentSpeed
-			if speed > 0.1 && bytesTotal > 0 {
-				remainingBytes := bytesTotal - bytesDownload
-				etaSeconds := float64(remainingBytes) / (speed * 1024 * 1024)
-				if etaSeconds > 0 {
-					eta = time.Duration(etaSeconds * float64(time.Second)).Round(time.Second).String()
-				}
-			}
-		} else {
-			speed = 0
-			eta = "paused"
-		}
+		elapsed := m.downloadElapsed()
+		speed, eta := m.downloadRate(bytesDownload, bytesTotal)
 
 		statusText := "Downloading"
 		if m.paused {
@@ -117,11 +89,15 @@ func (m *Model) View() string {
 			s.WriteString(fmt.Sprintf(" | ETA: %s", eta))
 		}
 		s.WriteString("\n\n")
+		s.WriteString(m.fileListView())
+		s.WriteString("\n")
 
 		if m.paused {
-			s.WriteString("[r] Resume [Esc] Cancel [Ctrl+C] Quit\n")
+			s.WriteString(hints(hint("Scroll", m.keys.Up, m.keys.Down), hint("", m.keys.Resume), hint("Cancel", m.keys.Cancel),
+				hint("Browse", m.keys.Progress), hint("Quit", m.keys.ForceQuit)) + "\n")
 		} else {
-			s.WriteString("[p] Pause [Ctrl+C] Quit\n")
+			s.WriteString(hints(hint("Scroll", m.keys.Up, m.keys.Down), hint("", m.keys.Pause),
+				hint("Browse", m.keys.Progress), hint("Quit", m.keys.ForceQuit)) + "\n")
 		}
 
 		if m.status != "" {
@@ -131,27 +107,24 @@ func (m *Model) View() string {
 		return s.String()
 	}
 
-	s := strings.Builder{}
+	if m.searching {
+		return m.searchView()
+	}
 
-	displayPath := "Root"
-	if m.currentPath != "" {
-		decodedPath, err := url.QueryUnescape(m.currentPath)
-		if err != nil {
-			decodedPath = m.currentPath
-		}
-		displayPath = decodedPath
+	if m.showBookmarks {
+		return m.bookmarksView()
 	}
 
-	title := fmt.Sprintf("Myrient Browser - %s", displayPath)
-	s.WriteString(lipgloss.NewStyle().Bold(true).Render(title) + "\n\n")
+	if m.showHelp {
+		return m.helpView()
+	}
 
-	if m.filtering {
-		s.WriteString("Filter: " + m.filterInput.View() + "\n")
-	} else if m.filterInput.Value() != "" {
-		s.WriteString(fmt.Sprintf("Filter: %s (press / to edit, Esc to clear)\n", m.filterInput.Value()))
+	if m.showQueue {
+		return m.queueView()
 	}
 
-	s.WriteString("\n")
+	s := strings.Builder{}
+	s.WriteString(m.header())
 
 	start := m.viewport.offset
 	end := m.viewport.offset + m.viewport.height
@@ -159,10 +132,13 @@ func (m *Model) View() string {
 		end = len(m.filtered)
 	}
 
+	list := strings.Builder{}
 	if start > 0 {
-		s.WriteString(" ↑ More items above...\n")
+		list.WriteString(" ↑ More items above...\n")
 	}
 
+	nameWidth := m.nameColumnWidth()
+
 	for i := start; i < end; i++ {
 		entry := m.entries[m.filtered[i]]
 		cursor := " "
@@ -180,13 +156,58 @@ func (m *Model) View() string {
 			style = style.Background(lipgloss.Color("62")).Foreground(lipgloss.Color("230"))
 		}
 
-		s.WriteString(style.Render(fmt.Sprintf("%s %s %s", cursor, icon, entry.Name)) + "\n")
+		size := formatSize(entry.Size)
+		if icon == "📁" {
+			size = "-"
+		}
+		mark := " "
+		if m.marked[entry.Path] {
+			mark = "+"
+			if i != m.cursor {
+				style = style.Foreground(lipgloss.Color("11"))
+			}
+		}
+
+		local := ""
+		localStyle := style
+		if icon == "📄" {
+			file := m.local[entry.Path]
+			local = file.label()
+			if i != m.cursor {
+				localStyle = lipgloss.NewStyle().Foreground(localColors[file.state])
+			}
+		}
+
+		name := highlightName(entry.Name, m.matches[m.filtered[i]], nameWidth, style)
+		list.WriteString(style.Render(fmt.Sprintf("%s%s %s ", cursor, mark, icon)) + name +
+			style.Render(fmt.Sprintf("  %10s  %16s  ", size, formatModTime(entry.ModTime))) +
+			localStyle.Render(runewidth.FillRight(local, 6)) + "\n")
 	}
 
 	if end < len(m.filtered) {
-		s.WriteString(" ↓ More items below...\n")
+		list.WriteString(" ↓ More items below...\n")
+	}
+
+	if m.showDetail {
+		listWidth, paneWidth := m.listWidth()
+		pane := lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder(), false, false, false, true).
+			BorderForeground(lipgloss.Color("240")).
+			PaddingLeft(1).
+			Width(paneWidth).
+			Render(strings.TrimSuffix(m.detailView(paneWidth-1), "\n"))
+		rows := lipgloss.NewStyle().Width(listWidth).Render(strings.TrimSuffix(list.String(), "\n"))
+		s.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, rows, pane) + "\n")
+	} else {
+		s.WriteString(list.String())
 	}
 
+	return s.String() + m.footer()
+}
+
+// footer renders the position, option toggles, key hints, status and
+// download bar below the listing.
+func (m *Model) footer() string {
 	totalEntries := len(m.entries)
 	filteredCount := len(m.filtered)
 	filterInfo := ""
@@ -220,16 +241,662 @@ func (m *Model) View() string {
 	}
 
 	// Build help text
-	help := fmt.Sprintf("\n[%d/%d]%s\n\n", m.cursor+1, filteredCount, filterInfo)
-	help += fmt.Sprintf("PreScan: %s Extract: %s Folder: %s Delete: %s\n\n",
+	markInfo := ""
+	if len(m.marked) > 0 {
+		markInfo = " " + lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render(m.markedSummary())
+	}
+
+	help := fmt.Sprintf("\n[%d/%d]%s%s\n\n", m.cursor+1, filteredCount, filterInfo, markInfo)
+	help += fmt.Sprintf("PreScan: %s Extract: %s Folder: %s Delete: %s",
 		preScanStatus, extractStatus, folderStatus, deleteStatus)
-	help += "Navigation: [↑↓] Move [PgUp/PgDn] Scroll [Home/End] Jump [/] Filter\n"
-	help += "Actions: [→/Enter] Open [d] Download All [←] Back [q] Quit\n"
-	help += "Options: [s] PreScan [x] Extract [f] Folder [z] Delete Zip"
+	if name, _ := m.opts.profileFor(m.currentPath); name != "" {
+		help += " Profile: " + lipgloss.NewStyle().Foreground(lipgloss.Color("12")).Render(name)
+	}
+	help += "\n\n"
+	k := m.keys
+	help += "Navigation: " + hints(hint("Move", k.Up, k.Down), hint("Scroll", k.PageUp, k.PageDown), hint("Jump", k.Home, k.End),
+		hint("", k.Filter), hint("", k.Sort), hint("", k.GoTo), hint("", k.Search), hint("", k.Index)) + "\n"
+	help += "Actions: " + hints(hint("", k.Open), hint("", k.Download), hint("", k.DownloadRecursive), hint("", k.Queue),
+		hint("", k.QueuePanel), hint("", k.Back), hint("", k.Forward), hint("", k.Help), hint("", k.Quit)) + "\n"
+	help += "Bookmarks: " + hints(hint("", k.Bookmark), hint("", k.Bookmarks)) + "\n"
+	help += "Marking: " + hints(hint("", k.Mark), hint("", k.MarkAll), hint("", k.InvertMarks), hint("", k.ClearMarks),
+		hint("", k.DownloadMarked)) + "\n"
+	help += "Options: " + hints(hint("", k.PreScan), hint("", k.Extract), hint("", k.Folder), hint("", k.DeleteZip),
+		hint("", k.FilterMode), hint("", k.MissingOnly), hint("", k.Details))
+
+	if m.status != "" {
+		help = "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("green")).Render(m.status) + help
+	}
+	if bar := m.downloadBar(); bar != "" {
+		help = "\n" + bar + help
+	}
+
+	return help
+}
+
+// layout fits the listing between the header and the footer as they are
+// currently rendered, keeping room for the lines that say there are more
+// items above or below, and scrolls the cursor back into view.
+func (m *Model) layout() {
+	if m.viewport.screenHeight == 0 {
+		return
+	}
+	chrome := m.listTop() + lipgloss.Height(m.footer()) + 2
+	m.viewport.height = max(m.viewport.screenHeight-chrome, 1)
+	if m.cursor >= m.viewport.offset+m.viewport.height {
+		m.viewport.offset = m.cursor - m.viewport.height + 1
+	}
+}
+
+// listTop returns the screen row of the first line of the listing, below
+// the header.
+func (m *Model) listTop() int {
+	return strings.Count(m.header(), "\n")
+}
+
+// header renders the breadcrumb bar and any prompt above the listing.
+func (m *Model) header() string {
+	s := strings.Builder{}
+	s.WriteString(m.breadcrumbBar() + m.titleLabels() + "\n\n")
+
+	if m.goingTo {
+		s.WriteString("Go to: " + m.gotoInput.View() + "\n")
+	} else if m.naming {
+		s.WriteString("Bookmark as: " + m.bookmarkInput.View() + "\n")
+	} else if m.filtering {
+		s.WriteString(fmt.Sprintf("Filter (%s, Tab to switch): ", m.filterMode()) + m.filterInput.View() + "\n")
+	} else if m.filterInput.Value() != "" {
+		s.WriteString(fmt.Sprintf("Filter (%s): %s (press %s to edit, then [Esc] to clear)\n",
+			m.filterMode(), m.filterInput.Value(), keyHint(m.keys.Filter)))
+	}
+	if m.filterErr != "" && (m.filtering || m.filterInput.Value() != "") {
+		s.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("red")).Render("⚠ "+m.filterErr) + "\n")
+	}
+
+	s.WriteString("\n")
+	return s.String()
+}
+
+// titleLabels renders the sort order and other listing state shown after the
+// breadcrumb bar.
+func (m *Model) titleLabels() string {
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	labels := labelStyle.Render(fmt.Sprintf("  [sort: %s]", m.sortMode))
+	if m.missingOnly {
+		labels += labelStyle.Render(" [missing only]")
+	}
+	if m.indexer != nil {
+		labels += labelStyle.Render(fmt.Sprintf(" [%s]", m.indexSummary()))
+	}
+	return labels
+}
+
+// searchView renders the search prompt and the ranked matches from the
+// search index.
+func (m *Model) searchView() string {
+	s := strings.Builder{}
+
+	title := lipgloss.NewStyle().Bold(true).Render("Myrient Browser - Search")
+	indexLabel := lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render(fmt.Sprintf("  [%s]", m.indexSummary()))
+	s.WriteString(title + indexLabel + "\n\n")
+	s.WriteString("Search: " + m.searchInput.View() + "\n\n")
+
+	start := m.searchOffset
+	end := min(m.searchOffset+m.viewport.height, len(m.searchResults))
+
+	if start > 0 {
+		s.WriteString(" ↑ More results above...\n")
+	}
+
+	// Cursor, queue mark, icon and spacing take 7 cells, size 12.
+	width := m.viewport.width
+	if width == 0 {
+		width = 80
+	}
+	nameWidth := max((width-19)*3/5, 20)
+	dirWidth := max(width-19-nameWidth, 10)
+	dirStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+	for i := start; i < end; i++ {
+		e := m.searchResults[i]
+		cursor := " "
+		if i == m.searchCursor {
+			cursor = ">"
+		}
+		mark := " "
+		if m.isQueued(e.Dir, e.Path) {
+			mark = "+"
+		}
+
+		icon := "📄"
+		size := formatSize(e.Size)
+		if e.isDir() {
+			icon = "📁"
+			size = "-"
+		}
+
+		name := runewidth.FillRight(runewidth.Truncate(strings.TrimSuffix(e.Name, "/"), nameWidth, "…"), nameWidth)
+		dir := runewidth.FillRight(runewidth.Truncate(decodePath(e.Dir), dirWidth, "…"), dirWidth)
+		row := fmt.Sprintf("%s%s %s %s  %10s ", cursor, mark, icon, name, size)
+		if i == m.searchCursor {
+			style := lipgloss.NewStyle().Background(lipgloss.Color("62")).Foreground(lipgloss.Color("230"))
+			s.WriteString(style.Render(row+dir) + "\n")
+		} else {
+			s.WriteString(row + dirStyle.Render(dir) + "\n")
+		}
+	}
+
+	if end < len(m.searchResults) {
+		s.WriteString(" ↓ More results below...\n")
+	}
+
+	switch {
+	case m.index == nil:
+		s.WriteString("No search index yet - press " + keyHint(m.keys.SearchClose) + " then " + keyHint(m.keys.Index) + " to build one\n")
+	case m.searchInput.Value() != "" && len(m.searchResults) == 0:
+		s.WriteString("No matches\n")
+	}
+
+	help := fmt.Sprintf("\n[%d/%d]", min(m.searchCursor+1, len(m.searchResults)), len(m.searchResults))
+	if len(m.queue.Items) > 0 {
+		help += fmt.Sprintf(" %d queued", len(m.queue.Items))
+	}
+	help += "\n\n"
+	k := m.keys
+	help += hints(hint("Move", k.SearchUp, k.SearchDown), hint("", k.SearchOpen), hint("", k.SearchQueue),
+		hint("", k.SearchDownload), hint("", k.SearchClose))
+
+	if m.status != "" {
+		help = "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("green")).Render(m.status) + help
+	}
+	if bar := m.downloadBar(); bar != "" {
+		help = "\n" + bar + help
+	}
+
+	return s.String() + help
+}
+
+// queueView renders the download queue panel.
+func (m *Model) queueView() string {
+	s := strings.Builder{}
+
+	title := lipgloss.NewStyle().Bold(true).Render("Myrient Browser - Download queue")
+	countLabel := lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render(fmt.Sprintf("  [%d items]", len(m.queue.Items)))
+	s.WriteString(title + countLabel + "\n\n")
+
+	if len(m.queue.Items) == 0 {
+		s.WriteString("The queue is empty - press " + keyHint(m.keys.Queue) + " on an entry to add it\n")
+	}
+
+	// Keep the cursor in view without tracking a separate offset.
+	height := max(m.viewport.height, 1)
+	start := max(m.queueCursor-height+1, 0)
+	end := min(start+height, len(m.queue.Items))
+
+	// Cursor, icon and spacing take 5 cells, size 12, status 19.
+	width := m.viewport.width
+	if width == 0 {
+		width = 80
+	}
+	nameWidth := max((width-36)/2, 20)
+	dirWidth := max(width-36-nameWidth, 10)
+	dirStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+	for i := start; i < end; i++ {
+		it := &m.queue.Items[i]
+		cursor := " "
+		if i == m.queueCursor {
+			cursor = ">"
+		}
+
+		icon := "📄"
+		size := formatSize(it.Entry.Size)
+		if it.isDir() {
+			icon = "📁"
+			size = "-"
+		}
+
+		status := m.queueItemStatus(it)
+		statusStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+		switch {
+		case status == queueStatusDone:
+			statusStyle = statusStyle.Foreground(lipgloss.Color("10"))
+		case status == queueStatusFailed:
+			statusStyle = statusStyle.Foreground(lipgloss.Color("9"))
+		case status != queueStatusQueued:
+			statusStyle = statusStyle.Foreground(lipgloss.Color("11"))
+		}
+
+		name := runewidth.FillRight(runewidth.Truncate(strings.TrimSuffix(it.Entry.Name, "/"), nameWidth, "…"), nameWidth)
+		dir := runewidth.FillRight(runewidth.Truncate(decodePath(it.BasePath), dirWidth, "…"), dirWidth)
+		statusCell := fmt.Sprintf("%-18s", status)
+		if i == m.queueCursor {
+			style := lipgloss.NewStyle().Background(lipgloss.Color("62")).Foreground(lipgloss.Color("230"))
+			s.WriteString(style.Render(fmt.Sprintf("%s %s %s  %10s  %s %s", cursor, icon, name, size, statusCell, dir)) + "\n")
+		} else {
+			s.WriteString(fmt.Sprintf("%s %s %s  %10s  ", cursor, icon, name, size) +
+				statusStyle.Render(statusCell) + " " + dirStyle.Render(dir) + "\n")
+		}
+	}
+
+	help := "\n"
+	if m.queueCursor < len(m.queue.Items) {
+		help += "Destination: " + m.queue.Items[m.queueCursor].OutputDir + "\n"
+	}
+	help += "\n" + hints(hint("Move", m.keys.Up, m.keys.Down), hint("Reorder", m.keys.MoveItemUp, m.keys.MoveItemDown),
+		hint("", m.keys.Remove), hint("", m.keys.ClearFinished), hint("Download queue", m.keys.Open), hint("", m.keys.Close))
 
 	if m.status != "" {
 		help = "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("green")).Render(m.status) + help
 	}
+	if bar := m.downloadBar(); bar != "" {
+		help = "\n" + bar + help
+	}
 
 	return s.String() + help
 }
+
+// filterMode names the current filter matching mode.
+func (m *Model) filterMode() string {
+	if m.substringFilter {
+		return "substring"
+	}
+	return "fuzzy"
+}
+
+// highlightName truncates or pads name to width cells and renders it with
+// base, emphasising the runes at the matched positions.
+func highlightName(name string, positions []int, width int, base lipgloss.Style) string {
+	truncated := runewidth.Truncate(name, width, "…")
+	padding := strings.Repeat(" ", max(width-runewidth.StringWidth(truncated), 0))
+	if len(positions) == 0 {
+		return base.Render(truncated + padding)
+	}
+
+	// The ellipsis of a truncated name doesn't correspond to a matched rune.
+	runes := []rune(truncated)
+	kept := len(runes)
+	if truncated != name {
+		kept--
+	}
+
+	highlight := base.Bold(true).Foreground(lipgloss.Color("212"))
+	matched := map[int]bool{}
+	for _, p := range positions {
+		matched[p] = true
+	}
+
+	var b strings.Builder
+	var run []rune
+	runMatched := false
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		if runMatched {
+			b.WriteString(highlight.Render(string(run)))
+		} else {
+			b.WriteString(base.Render(string(run)))
+		}
+		run = run[:0]
+	}
+	for i, r := range runes {
+		isMatch := i < kept && matched[i]
+		if isMatch != runMatched {
+			flush()
+			runMatched = isMatch
+		}
+		run = append(run, r)
+	}
+	flush()
+
+	return b.String() + base.Render(padding)
+}
+
+// nameColumnWidth returns how much of the terminal width the name column can
+// use next to the cursor, icon, size and date columns.
+func (m *Model) nameColumnWidth() int {
+	width, _ := m.listWidth()
+	// Cursor, mark, icon and spacing take 6 cells, size and date 30, local
+	// state 8.
+	return max(width-44, 20)
+}
+
+// listWidth returns the width of the listing and of the detail pane next to
+// it, which is 0 while the pane is closed. The pane's border takes a cell.
+func (m *Model) listWidth() (list, pane int) {
+	width := m.viewport.width
+	if width == 0 {
+		width = 80
+	}
+	if !m.showDetail {
+		return width, 0
+	}
+	pane = detailPaneWidth(width)
+	return width - pane - 1, pane
+}
+
+// localColors are the colors of the local state column.
+var localColors = map[localState]lipgloss.Color{
+	localMissing:  lipgloss.Color("240"),
+	localPartial:  lipgloss.Color("11"),
+	localMismatch: lipgloss.Color("9"),
+	localComplete: lipgloss.Color("10"),
+}
+
+// downloadElapsed returns how long the download has been running, not
+// counting pauses.
+func (m *Model) downloadElapsed() time.Duration {
+	if m.paused {
+		return m.pausedTime + time.Since(m.pauseStart)
+	}
+	return m.pausedTime + time.Since(m.startTime)
+}
+
+// downloadRate returns the smoothed download speed in MB/s and the time left
+// at that speed.
+func (m *Model) downloadRate(bytesDownload, bytesTotal int64) (float64, string) {
+	if m.paused {
+		return 0, "paused"
+	}
+
+	now := time.Now()
+	if m.downloadStats.lastTime.IsZero() {
+		m.downloadStats.lastTime = m.startTime
+		m.downloadStats.lastBytes = 0
+	}
+
+	timeDiff := now.Sub(m.downloadStats.lastTime).Seconds()
+	if timeDiff >= 0.1 {
+		bytesDiff := bytesDownload - m.downloadStats.lastBytes
+		instantSpeed := float64(bytesDiff) / timeDiff / 1024 / 1024
+
+		if m.downloadStats.currentSpeed == 0 {
+			m.downloadStats.currentSpeed = instantSpeed
+		} else {
+			m.downloadStats.currentSpeed = 0.7*m.downloadStats.currentSpeed + 0.3*instantSpeed
+		}
+
+		m.downloadStats.lastTime = now
+		m.downloadStats.lastBytes = bytesDownload
+	}
+
+	speed := m.downloadStats.currentSpeed
+	eta := "calculating..."
+	if speed > 0.1 && bytesTotal > 0 {
+		remainingBytes := bytesTotal - bytesDownload
+		etaSeconds := float64(remainingBytes) / (speed * 1024 * 1024)
+		if etaSeconds > 0 {
+			eta = time.Duration(etaSeconds * float64(time.Second)).Round(time.Second).String()
+		}
+	}
+	return speed, eta
+}
+
+// downloadBar summarises a download running in the background on a single
+// line, or returns "" if there is none.
+func (m *Model) downloadBar() string {
+	if !m.downloading || m.downloadStats == nil {
+		return ""
+	}
+	stats := m.downloadStats
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color("12"))
+
+	var text string
+	switch {
+	case stats.extracting:
+		text = fmt.Sprintf("Extracting %d/%d files", atomic.LoadInt32(&stats.extracted), atomic.LoadInt32(&stats.toExtract))
+	case stats.crawling:
+		text = fmt.Sprintf("Listing directories: %d directories, %d files found",
+			atomic.LoadInt32(&stats.dirsCrawled), atomic.LoadInt32(&stats.filesFound))
+	case stats.scanning:
+		text = fmt.Sprintf("Scanning %d/%d files", atomic.LoadInt32(&stats.scanProgress), stats.total)
+	default:
+		completed := atomic.LoadInt32(&stats.completed)
+		bytesDownload := atomic.LoadInt64(&stats.bytesDownload)
+		bytesTotal := atomic.LoadInt64(&stats.bytesTotal)
+
+		percent := float64(completed) / float64(max(stats.total, 1))
+		if bytesTotal > 0 {
+			percent = float64(bytesDownload) / float64(bytesTotal)
+		}
+		speed, eta := m.downloadRate(bytesDownload, bytesTotal)
+
+		text = fmt.Sprintf("%d/%d files (%.1f%%)", completed, stats.total, percent*100)
+		if m.paused {
+			text += " PAUSED"
+			style = style.Foreground(lipgloss.Color("yellow"))
+		} else {
+			text += fmt.Sprintf(" %.2f MB/s", speed)
+			if bytesTotal > 0 {
+				text += " ETA " + eta
+			}
+		}
+	}
+
+	keys := hints(hint("", m.keys.Progress), hint("", m.keys.Pause), hint("", m.keys.Resume))
+	return style.Render("⬇ "+text) + lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render("  "+keys)
+}
+
+// progressOrder returns the indexes of the jobs in the order they are
+// listed: active files first, then those still queued, then finished ones
+// with the most recent first.
+func progressOrder(files []fileProgress) []int {
+	var active, finished, queued []int
+	for i := range files {
+		switch atomic.LoadInt32(&files[i].status) {
+		case fileActive:
+			active = append(active, i)
+		case fileQueued:
+			queued = append(queued, i)
+		default:
+			finished = append(finished, i)
+		}
+	}
+	slices.SortStableFunc(finished, func(a, b int) int {
+		return cmp.Compare(atomic.LoadInt64(&files[b].finished), atomic.LoadInt64(&files[a].finished))
+	})
+	return slices.Concat(active, queued, finished)
+}
+
+// sampleSpeed updates the smoothed download speed of the file in MB/s.
+func (p *fileProgress) sampleSpeed(now time.Time) float64 {
+	bytes := atomic.LoadInt64(&p.bytes)
+	if p.lastTime.IsZero() {
+		p.lastTime, p.lastBytes = now, bytes
+		return 0
+	}
+
+	if timeDiff := now.Sub(p.lastTime).Seconds(); timeDiff >= 0.5 {
+		instantSpeed := float64(bytes-p.lastBytes) / timeDiff / 1024 / 1024
+		if p.speed == 0 {
+			p.speed = instantSpeed
+		} else {
+			p.speed = 0.7*p.speed + 0.3*instantSpeed
+		}
+		p.lastTime, p.lastBytes = now, bytes
+	}
+	return p.speed
+}
+
+// fileListView renders the scrollable list of download jobs shown under the
+// progress bar.
+func (m *Model) fileListView() string {
+	files := m.downloadStats.files
+	if len(files) == 0 {
+		return ""
+	}
+
+	height := m.viewport.height
+	if height <= 0 {
+		height = 10
+	}
+	order := progressOrder(files)
+	m.progressOffset = max(min(m.progressOffset, len(order)-height), 0)
+	end := min(m.progressOffset+height, len(order))
+
+	// Icon and spacing take 4 cells, bytes 21, speed 12 and status 9.
+	width := m.viewport.width
+	if width == 0 {
+		width = 80
+	}
+	nameWidth := max(width-46, 20)
+
+	s := strings.Builder{}
+	if m.progressOffset > 0 {
+		s.WriteString(" ↑ More files above...\n")
+	}
+
+	now := time.Now()
+	for _, i := range order[m.progressOffset:end] {
+		p := &files[i]
+		status := atomic.LoadInt32(&p.status)
+		bytes := atomic.LoadInt64(&p.bytes)
+		size := atomic.LoadInt64(&p.size)
+
+		progress := formatSize(bytes)
+		if size > 0 {
+			progress = formatSize(bytes) + " / " + formatSize(size)
+		}
+		if status == fileQueued {
+			progress = "-"
+			if size > 0 {
+				progress = formatSize(size)
+			}
+		}
+
+		speed := ""
+		if status == fileActive && !m.paused {
+			speed = fmt.Sprintf("%.2f MB/s", p.sampleSpeed(now))
+		}
+
+		var label string
+		style := lipgloss.NewStyle()
+		switch status {
+		case fileQueued:
+			label = "queued"
+			style = style.Foreground(lipgloss.Color("240"))
+		case fileActive:
+			label = "active"
+			style = style.Foreground(lipgloss.Color("12"))
+		case fileDone:
+			label = "done"
+			style = style.Foreground(lipgloss.Color("10"))
+		case fileFailed:
+			label = "failed"
+			style = style.Foreground(lipgloss.Color("9"))
+		case fileSkipped:
+			label = "skipped"
+			style = style.Foreground(lipgloss.Color("240"))
+		}
+
+		name := runewidth.FillRight(runewidth.Truncate(p.name, nameWidth, "…"), nameWidth)
+		s.WriteString(fmt.Sprintf("  %s  %21s  %10s  ", name, progress, speed) + style.Render(label) + "\n")
+	}
+
+	if end < len(order) {
+		s.WriteString(" ↓ More files below...\n")
+	}
+	return s.String()
+}
+
+// bookmarksView renders the bookmark picker as a box over the middle of the
+// screen.
+func (m *Model) bookmarksView() string {
+	s := strings.Builder{}
+	s.WriteString(lipgloss.NewStyle().Bold(true).Render("Bookmarks") + "\n\n")
+
+	if len(m.bookmarks) == 0 {
+		s.WriteString("No bookmarks yet - press " + keyHint(m.keys.Bookmark) + " in a directory to add one\n")
+	}
+
+	nameWidth := 0
+	for _, b := range m.bookmarks {
+		nameWidth = max(nameWidth, runewidth.StringWidth(b.Name))
+	}
+	pathStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	selected := lipgloss.NewStyle().Background(lipgloss.Color("62")).Foreground(lipgloss.Color("230"))
+
+	for i, b := range m.bookmarks {
+		name := runewidth.FillRight(b.Name, nameWidth)
+		path := runewidth.Truncate(b.Path, 60, "…")
+		if i == m.bookmarkCursor {
+			s.WriteString(selected.Render(fmt.Sprintf("> %s  %s", name, path)) + "\n")
+		} else {
+			s.WriteString("  " + name + "  " + pathStyle.Render(path) + "\n")
+		}
+	}
+
+	s.WriteString("\n")
+	if m.naming {
+		s.WriteString("Rename to: " + m.bookmarkInput.View() + "\n\n")
+	}
+	if m.status != "" {
+		s.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("green")).Render(m.status) + "\n\n")
+	}
+	s.WriteString(hints(hint("Move", m.keys.Up, m.keys.Down), hint("", m.keys.Open), hint("Rename", m.keys.Rename),
+		hint("Delete", m.keys.Remove), hint("", m.keys.Close)))
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(1, 2).
+		Render(s.String())
+
+	if m.viewport.width == 0 {
+		return box
+	}
+	return lipgloss.Place(m.viewport.width, m.viewport.screenHeight, lipgloss.Center, lipgloss.Center, box)
+}
+
+// helpView renders every binding of the active keymap by group, as many
+// groups side by side as fit.
+func (m *Model) helpView() string {
+	width := m.viewport.width
+	if width == 0 {
+		width = 80
+	}
+	// The box's border and padding take 4 cells.
+	width -= 4
+
+	titleStyle := lipgloss.NewStyle().Bold(true)
+	keyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("12"))
+	column := lipgloss.NewStyle().PaddingRight(4)
+
+	var rows, row []string
+	rowWidth := 0
+	for _, g := range m.keys.groups() {
+		keyWidth := 0
+		for _, b := range g.bindings {
+			keyWidth = max(keyWidth, lipgloss.Width(b.Help().Key))
+		}
+
+		lines := []string{titleStyle.Render(g.title)}
+		for _, b := range g.bindings {
+			if b.Enabled() {
+				lines = append(lines, keyStyle.Width(keyWidth+2).Render(b.Help().Key)+b.Help().Desc)
+			}
+		}
+		block := column.Render(strings.Join(lines, "\n"))
+
+		if len(row) > 0 && rowWidth+lipgloss.Width(block) > width {
+			rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, row...))
+			row, rowWidth = nil, 0
+		}
+		row = append(row, block)
+		rowWidth += lipgloss.Width(block)
+	}
+	rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, row...))
+
+	s := titleStyle.Render("Keys") + "\n\n" + strings.Join(rows, "\n\n") + "\n\nPress any key to close"
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(0, 1).
+		Render(s)
+
+	if m.viewport.width == 0 {
+		return box
+	}
+	return lipgloss.Place(m.viewport.width, m.viewport.screenHeight, lipgloss.Center, lipgloss.Center, box)
+}
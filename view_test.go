@@ -0,0 +1,169 @@
+package myrient_browser
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// longPages is a mirror whose root lists more files than fit on a screen.
+var longPages = func() map[string]string {
+	items := make([]string, 100)
+	for i := range items {
+		items[i] = fmt.Sprintf(`{"name":"file %02d.zip","type":"file","size":1024}`, i)
+	}
+	return map[string]string{"/files/": "[" + strings.Join(items, ",") + "]"}
+}()
+
+func TestViewFitsScreen(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(m *Model)
+	}{
+		{"idle", func(*Model) {}},
+		{"naming a bookmark", func(m *Model) {
+			m.naming = true
+		}},
+		{"naming a bookmark at the end of the listing", func(m *Model) {
+			m.setCursor(len(m.filtered) - 1)
+			m.naming = true
+		}},
+		{"downloading", func(m *Model) {
+			m.beginDownload(m.downloadOptions(), &downloadStats{total: 3})
+		}},
+		{"downloading with a status", func(m *Model) {
+			m.beginDownload(m.downloadOptions(), &downloadStats{total: 3})
+			m.status = "Downloading 3 files..."
+		}},
+		{"downloading at the end of the listing", func(m *Model) {
+			m.beginDownload(m.downloadOptions(), &downloadStats{total: 3})
+			m.setCursor(len(m.filtered) - 1)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel(t, longPages)
+			m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+			tt.setup(m)
+			m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+
+			view := m.View()
+			if h := lipgloss.Height(view); h > 40 {
+				t.Errorf("view is %d lines high, want at most 40", h)
+			}
+			if !strings.HasPrefix(view, lipgloss.NewStyle().Bold(true).Render(breadcrumbTitle)) {
+				t.Errorf("view doesn't start with the breadcrumb bar: %q", strings.SplitN(view, "\n", 2)[0])
+			}
+			cursor := fmt.Sprintf("file %02d.zip", m.cursor)
+			if !strings.Contains(view, cursor) {
+				t.Errorf("the cursor row %q is not shown", cursor)
+			}
+		})
+	}
+}
+
+func TestProgressOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   []int32
+		finished []int64
+		want     []int
+	}{
+		{"empty", nil, nil, nil},
+		{"all queued", []int32{fileQueued, fileQueued}, []int64{0, 0}, []int{0, 1}},
+		{
+			"active, queued, finished",
+			[]int32{fileDone, fileQueued, fileActive, fileFailed, fileQueued, fileActive},
+			[]int64{1, 0, 0, 2, 0, 0},
+			[]int{2, 5, 1, 4, 3, 0},
+		},
+		{
+			"most recently finished first",
+			[]int32{fileDone, fileSkipped, fileFailed, fileDone},
+			[]int64{3, 1, 4, 3},
+			[]int{2, 0, 3, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := make([]fileProgress, len(tt.status))
+			for i := range files {
+				files[i].status, files[i].finished = tt.status[i], tt.finished[i]
+			}
+			if got := progressOrder(files); !slices.Equal(got, tt.want) {
+				t.Errorf("progressOrder = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSampleSpeed(t *testing.T) {
+	const mb = 1024 * 1024
+	start := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	type sample struct {
+		after time.Duration
+		bytes int64
+		want  float64
+	}
+	tests := []struct {
+		name    string
+		samples []sample
+	}{
+		{"first sample", []sample{{0, 5 * mb, 0}}},
+		{"inside the window", []sample{{0, 0, 0}, {400 * time.Millisecond, mb, 0}}},
+		{"window elapsed", []sample{{0, 0, 0}, {500 * time.Millisecond, mb, 2}}},
+		{"window measured from the last sample", []sample{{0, 0, 0}, {time.Second, 2 * mb, 2}, {1400 * time.Millisecond, 10 * mb, 2}}},
+		{"smoothed", []sample{{0, 0, 0}, {time.Second, 2 * mb, 2}, {2 * time.Second, 6 * mb, 0.7*2 + 0.3*4}}},
+		{"stalled", []sample{{0, 0, 0}, {time.Second, 2 * mb, 2}, {2 * time.Second, 2 * mb, 0.7 * 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p fileProgress
+			for i, s := range tt.samples {
+				p.bytes = s.bytes
+				if got := p.sampleSpeed(start.Add(s.after)); math.Abs(got-s.want) > 1e-9 {
+					t.Errorf("sample %d: speed = %v MB/s, want %v", i, got, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestFileListView(t *testing.T) {
+	m := InitialModel(DefaultOptions())
+	m.viewport.height = 2
+	m.downloadStats = &downloadStats{files: []fileProgress{
+		{name: "done.zip", status: fileDone, finished: 1, bytes: 4, size: 4},
+		{name: "queued.zip", status: fileQueued, size: 4},
+		{name: "active.zip", status: fileActive, bytes: 2, size: 4},
+	}}
+
+	tests := []struct {
+		offset int
+		want   []string
+	}{
+		{0, []string{"active.zip", "queued.zip", "More files below"}},
+		{1, []string{"More files above", "queued.zip", "done.zip"}},
+		{5, []string{"More files above", "queued.zip", "done.zip"}},
+	}
+	for _, tt := range tests {
+		m.progressOffset = tt.offset
+		lines := strings.Split(strings.TrimSuffix(m.fileListView(), "\n"), "\n")
+		if len(lines) != len(tt.want) {
+			t.Errorf("offset %d: %d lines, want %d: %q", tt.offset, len(lines), len(tt.want), lines)
+			continue
+		}
+		for i, want := range tt.want {
+			if !strings.Contains(lines[i], want) {
+				t.Errorf("offset %d: line %d = %q, want it to contain %q", tt.offset, i, lines[i], want)
+			}
+		}
+	}
+}